@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+
+	autoscaling "k8s.io/api/autoscaling/v2"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+)
+
+// FromV2Beta2 converts a slice of autoscaling/v2beta2 MetricSpecs, as served by clusters older than Kubernetes
+// 1.26, into the canonical MetricSpec type used throughout this project.
+func FromV2Beta2(specs []autoscalingv2beta2.MetricSpec) ([]MetricSpec, error) {
+	converted := make([]MetricSpec, 0, len(specs))
+	for _, spec := range specs {
+		convertedSpec, err := metricSpecFromV2Beta2(spec)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, convertedSpec)
+	}
+	return converted, nil
+}
+
+func metricSpecFromV2Beta2(spec autoscalingv2beta2.MetricSpec) (MetricSpec, error) {
+	converted := MetricSpec{
+		Type: autoscaling.MetricSourceType(spec.Type),
+	}
+
+	switch spec.Type {
+	case autoscalingv2beta2.ObjectMetricSourceType:
+		converted.Object = &autoscaling.ObjectMetricSource{
+			DescribedObject: autoscaling.CrossVersionObjectReference{
+				Kind:       spec.Object.DescribedObject.Kind,
+				Name:       spec.Object.DescribedObject.Name,
+				APIVersion: spec.Object.DescribedObject.APIVersion,
+			},
+			Target: metricTargetFromV2Beta2(spec.Object.Target),
+			Metric: metricIdentifierFromV2Beta2(spec.Object.Metric),
+		}
+	case autoscalingv2beta2.PodsMetricSourceType:
+		converted.Pods = &autoscaling.PodsMetricSource{
+			Metric: metricIdentifierFromV2Beta2(spec.Pods.Metric),
+			Target: metricTargetFromV2Beta2(spec.Pods.Target),
+		}
+	case autoscalingv2beta2.ResourceMetricSourceType:
+		converted.Resource = &autoscaling.ResourceMetricSource{
+			Name:   spec.Resource.Name,
+			Target: metricTargetFromV2Beta2(spec.Resource.Target),
+		}
+	case autoscalingv2beta2.ContainerResourceMetricSourceType:
+		converted.ContainerResource = &autoscaling.ContainerResourceMetricSource{
+			Name:      spec.ContainerResource.Name,
+			Container: spec.ContainerResource.Container,
+			Target:    metricTargetFromV2Beta2(spec.ContainerResource.Target),
+		}
+	case autoscalingv2beta2.ExternalMetricSourceType:
+		converted.External = &autoscaling.ExternalMetricSource{
+			Metric: metricIdentifierFromV2Beta2(spec.External.Metric),
+			Target: metricTargetFromV2Beta2(spec.External.Target),
+		}
+	default:
+		return MetricSpec{}, fmt.Errorf("unknown autoscaling/v2beta2 metric source type %q", spec.Type)
+	}
+
+	return converted, nil
+}
+
+func metricIdentifierFromV2Beta2(identifier autoscalingv2beta2.MetricIdentifier) autoscaling.MetricIdentifier {
+	return autoscaling.MetricIdentifier{
+		Name:     identifier.Name,
+		Selector: identifier.Selector,
+	}
+}
+
+func metricTargetFromV2Beta2(target autoscalingv2beta2.MetricTarget) autoscaling.MetricTarget {
+	return autoscaling.MetricTarget{
+		Type:               autoscaling.MetricTargetType(target.Type),
+		Value:              target.Value,
+		AverageValue:       target.AverageValue,
+		AverageUtilization: target.AverageUtilization,
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/apis/internal"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestFromV2Beta2(t *testing.T) {
+	var cpuUtilization int32 = 80
+
+	equations := map[string]struct {
+		specs     []autoscalingv2beta2.MetricSpec
+		expected  []internal.MetricSpec
+		expectErr bool
+	}{
+		"resource metric": {
+			specs: []autoscalingv2beta2.MetricSpec{
+				{
+					Type: autoscalingv2beta2.ResourceMetricSourceType,
+					Resource: &autoscalingv2beta2.ResourceMetricSource{
+						Name: "cpu",
+						Target: autoscalingv2beta2.MetricTarget{
+							Type:               autoscalingv2beta2.UtilizationMetricType,
+							AverageUtilization: &cpuUtilization,
+						},
+					},
+				},
+			},
+			expected: []internal.MetricSpec{
+				{
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name: "cpu",
+						Target: autoscaling.MetricTarget{
+							Type:               autoscaling.UtilizationMetricType,
+							AverageUtilization: &cpuUtilization,
+						},
+					},
+				},
+			},
+		},
+		"object metric": {
+			specs: []autoscalingv2beta2.MetricSpec{
+				{
+					Type: autoscalingv2beta2.ObjectMetricSourceType,
+					Object: &autoscalingv2beta2.ObjectMetricSource{
+						DescribedObject: autoscalingv2beta2.CrossVersionObjectReference{
+							Kind: "Ingress",
+							Name: "main-route",
+						},
+						Metric: autoscalingv2beta2.MetricIdentifier{Name: "requests-per-second"},
+						Target: autoscalingv2beta2.MetricTarget{
+							Type:  autoscalingv2beta2.ValueMetricType,
+							Value: resourceQuantity("100"),
+						},
+					},
+				},
+			},
+			expected: []internal.MetricSpec{
+				{
+					Type: autoscaling.ObjectMetricSourceType,
+					Object: &autoscaling.ObjectMetricSource{
+						DescribedObject: autoscaling.CrossVersionObjectReference{
+							Kind: "Ingress",
+							Name: "main-route",
+						},
+						Metric: autoscaling.MetricIdentifier{Name: "requests-per-second"},
+						Target: autoscaling.MetricTarget{
+							Type:  autoscaling.ValueMetricType,
+							Value: resourceQuantity("100"),
+						},
+					},
+				},
+			},
+		},
+		"container resource metric": {
+			specs: []autoscalingv2beta2.MetricSpec{
+				{
+					Type: autoscalingv2beta2.ContainerResourceMetricSourceType,
+					ContainerResource: &autoscalingv2beta2.ContainerResourceMetricSource{
+						Name:      "cpu",
+						Container: "main",
+						Target: autoscalingv2beta2.MetricTarget{
+							Type:               autoscalingv2beta2.UtilizationMetricType,
+							AverageUtilization: &cpuUtilization,
+						},
+					},
+				},
+			},
+			expected: []internal.MetricSpec{
+				{
+					Type: autoscaling.ContainerResourceMetricSourceType,
+					ContainerResource: &autoscaling.ContainerResourceMetricSource{
+						Name:      "cpu",
+						Container: "main",
+						Target: autoscaling.MetricTarget{
+							Type:               autoscaling.UtilizationMetricType,
+							AverageUtilization: &cpuUtilization,
+						},
+					},
+				},
+			},
+		},
+		"unknown metric source type": {
+			specs: []autoscalingv2beta2.MetricSpec{
+				{Type: "invalid"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for name, equation := range equations {
+		t.Run(name, func(t *testing.T) {
+			converted, err := internal.FromV2Beta2(equation.specs)
+			if equation.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !cmp.Equal(equation.expected, converted) {
+				t.Errorf("converted specs mismatch (-want +got):\n%s", cmp.Diff(equation.expected, converted))
+			}
+		})
+	}
+}
+
+func resourceQuantity(value string) *resource.Quantity {
+	q := resource.MustParse(value)
+	return &q
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package internal provides a version-agnostic metric spec/status type, letting the rest of the project
+// (metric, evaluate, fake) consume a single type regardless of whether the cluster the HPA is running against
+// supports the autoscaling/v2 or the older autoscaling/v2beta2 API. autoscaling/v2beta2 was removed in
+// Kubernetes 1.26, but clusters still running older versions only expose v2beta2, so both have to be supported.
+//
+// MetricSpec and MetricStatus are aliases for their autoscaling/v2 equivalents, so every existing caller already
+// consumes this package's types without any changes. The FromV2Beta2* conversion functions translate the older
+// API's specs into this canonical form, used when main.go detects that the API server doesn't support v2.
+package internal
+
+import (
+	autoscaling "k8s.io/api/autoscaling/v2"
+)
+
+// MetricSpec is the canonical, version-agnostic metric spec type that the rest of the project is built around.
+type MetricSpec = autoscaling.MetricSpec
+
+// MetricStatus is the canonical, version-agnostic metric status type that the rest of the project is built around.
+type MetricStatus = autoscaling.MetricStatus
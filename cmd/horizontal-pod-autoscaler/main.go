@@ -16,15 +16,21 @@ limitations under the License.
 
 // Horizontal Pod Autoscaler provides executable Horizontal Pod Autoscaler logic, which
 // can be built into a Custom Pod Autoscaler.
-// The Horizontal Pod Autoscaler has two modes, metric gathering and evaluation.
+// The Horizontal Pod Autoscaler has three modes, metric gathering, evaluation and server.
 // Metric mode gathers metrics, taking in a resource to get the metrics for and outputting
-// these metrics as serialised JSON.
+// these metrics as serialised JSON. Metric specs are decoded against whichever autoscaling API version the
+// cluster's API server supports, autoscaling/v2 or the older autoscaling/v2beta2 (removed in Kubernetes 1.26),
+// using the version-agnostic apis/internal package.
 // Evaluation mode makes decisions on how many replicas a resource should have, taking in
 // metrics and outputting evaluation decisions as seralised JSON.
+// Server mode starts a long-running HTTP+JSON server over a unix socket, building its
+// Kubernetes clients, metric gatherer and evaluator once at startup and reusing them across
+// requests, rather than rebuilding them on every metric or evaluate invocation.
 package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -36,20 +42,37 @@ import (
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+
 	cpametric "github.com/jthomperoo/custom-pod-autoscaler/metric"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/apis/internal"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/aggregate"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/annotation"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/external"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/node"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prediction"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheusclient"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/podclient"
-	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
 	cacheddiscovery "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
+	k8sscale "k8s.io/client-go/scale"
 	"k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
 	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 	customclient "k8s.io/metrics/pkg/client/custom_metrics"
@@ -60,16 +83,29 @@ const (
 	defaultTolerance = float64(0.1)
 	// 5 minute CPU initialization period
 	defaultCPUInitializationPeriod = 300
+	// 5 minute memory initialization period
+	defaultMemoryInitializationPeriod = 300
 	// 30 second initial readiness delay
 	defaultInitialReadinessDelay = 30
+	// max takes the highest replica count proposed by any metric, matching the classic HPA
+	defaultEvaluationStrategy = "max"
+	// 10 second Prometheus query timeout
+	defaultPrometheusQueryTimeout = 10
+	// 5 minute prediction horizon
+	defaultPredictionHorizon = 300
+	// Holt-Winters suits both trending and seasonal series, making it the more broadly applicable default; users
+	// with a known, stationary daily cycle can opt into the lighter-weight PeriodicPredictor (with a 24 hour period)
+	// instead.
+	defaultPredictionStrategy = "holtwinters"
 )
 
 // EvaluateSpec represents the information fed to the evaluator
 type EvaluateSpec struct {
-	Metrics              []*cpametric.Metric       `json:"metrics"`
-	UnstructuredResource unstructured.Unstructured `json:"resource"`
-	Resource             metav1.Object             `json:"-"`
-	RunType              string                    `json:"runType"`
+	Metrics              []*cpametric.Metric                          `json:"metrics"`
+	UnstructuredResource unstructured.Unstructured                    `json:"resource"`
+	Resource             metav1.Object                                `json:"-"`
+	RunType              string                                       `json:"runType"`
+	Behavior             *autoscaling.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
 }
 
 // MetricSpec represents the information fed to the metric gatherer
@@ -80,15 +116,59 @@ type MetricSpec struct {
 }
 
 func main() {
+	modePtr := flag.String("mode", "no_mode", "command mode, either metric, evaluate or server")
+	socketPtr := flag.String("socket", defaultSocket, "unix socket to listen on in server mode")
+	flag.Parse()
+
+	if *modePtr == "server" {
+		tolerance, err := parseFloat64EnvVar("tolerance", defaultTolerance)
+		if err != nil {
+			log.Fatalf("Invalid tolerance provided - %e\n", err)
+			os.Exit(1)
+		}
+		cpuInitializationPeriod, err := parseInt64EnvVar("cpuInitializationPeriod", defaultCPUInitializationPeriod)
+		if err != nil {
+			log.Fatalf("Invalid CPU initialization period provided - %e\n", err)
+			os.Exit(1)
+		}
+		memoryInitializationPeriod, err := parseInt64EnvVar("memoryInitializationPeriod", defaultMemoryInitializationPeriod)
+		if err != nil {
+			log.Fatalf("Invalid memory initialization period provided - %e\n", err)
+			os.Exit(1)
+		}
+		initialReadinessDelay, err := parseInt64EnvVar("initialReadinessDelay", defaultInitialReadinessDelay)
+		if err != nil {
+			log.Fatalf("Invalid initial readiness delay provided - %e\n", err)
+			os.Exit(1)
+		}
+		evaluationStrategy, exists := os.LookupEnv("evaluationStrategy")
+		if !exists {
+			evaluationStrategy = defaultEvaluationStrategy
+		}
+		aggregator, err := buildAggregator(evaluationStrategy)
+		if err != nil {
+			log.Fatal(err)
+			os.Exit(1)
+		}
+
+		srv, err := newServer(tolerance, time.Duration(cpuInitializationPeriod)*time.Second, time.Duration(memoryInitializationPeriod)*time.Second, time.Duration(initialReadinessDelay)*time.Second, aggregator)
+		if err != nil {
+			log.Fatal(err)
+			os.Exit(1)
+		}
+		if err := runServer(srv, *socketPtr); err != nil {
+			log.Fatal(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	stdin, err := ioutil.ReadAll(os.Stdin)
 	if err != nil {
 		log.Fatal(err)
 		os.Exit(1)
 	}
 
-	modePtr := flag.String("mode", "no_mode", "command mode, either metric or evaluate")
-	flag.Parse()
-
 	switch *modePtr {
 	case "metric":
 		getMetrics(bytes.NewReader(stdin))
@@ -100,6 +180,166 @@ func main() {
 	}
 }
 
+func parseInt64EnvVar(name string, defaultValue int64) (int64, error) {
+	envVarVal, exists := os.LookupEnv(name)
+	if !exists {
+		return defaultValue, nil
+	}
+	return strconv.ParseInt(envVarVal, 10, 64)
+}
+
+func parseFloat64EnvVar(name string, defaultValue float64) (float64, error) {
+	envVarVal, exists := os.LookupEnv(name)
+	if !exists {
+		return defaultValue, nil
+	}
+	return strconv.ParseFloat(envVarVal, 64)
+}
+
+// buildAggregator resolves the evaluationStrategy configuration value into the aggregate.Aggregator it names.
+func buildAggregator(strategy string) (aggregate.Aggregator, error) {
+	switch strategy {
+	case "max":
+		return aggregate.Max{}, nil
+	case "min":
+		return aggregate.Min{}, nil
+	case "mean":
+		return aggregate.Mean{}, nil
+	case "median":
+		return aggregate.Median{}, nil
+	case "weighted":
+		return aggregate.Weighted{}, nil
+	default:
+		return nil, fmt.Errorf("unknown evaluation strategy %q", strategy)
+	}
+}
+
+// buildMetricsClient returns the MetricsClient backend the gatherer should use. If promAPI is non-nil (built by
+// buildPrometheusAPI from the "prometheusAddress" configuration variable), metrics are read directly from
+// Prometheus over its HTTP API via prometheusclient, bypassing restMetricsClient entirely so no
+// metrics-server/custom-metrics/external-metrics adapter needs to be deployed in front of Prometheus. Otherwise
+// restMetricsClient (the standard adapter-backed client) is used, wrapped in the local MetricsClient interface.
+func buildMetricsClient(restMetricsClient metrics.MetricsClient, promAPI promv1.API) metric.MetricsClient {
+	if promAPI == nil {
+		return metric.NewMetricsClientAdapter(restMetricsClient)
+	}
+
+	return &prometheusclient.Client{
+		API:       promAPI,
+		Templater: &prometheusclient.DefaultQueryTemplater{},
+	}
+}
+
+// buildPrometheusAPI builds a Prometheus HTTP API client for the "prometheusAddress" configuration variable,
+// retrying a request that fails with a network error or a 5xx response a handful of times before giving up, since
+// a single transient Prometheus or network hiccup shouldn't fail an entire gather. Returns a nil client, with no
+// error, if address is empty.
+func buildPrometheusAPI(address string) (promv1.API, error) {
+	if address == "" {
+		return nil, nil
+	}
+
+	client, err := api.NewClient(api.Config{
+		Address:      address,
+		RoundTripper: &prometheus.RetryRoundTripper{MaxRetries: 3, BaseDelay: time.Second},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prometheus client for address %q: %v", address, err)
+	}
+	return promv1.NewAPI(client), nil
+}
+
+// buildPrometheusGatherer returns a prometheus.Gatherer handling MetricSpecs of prometheus.MetricSourceType,
+// configured with the "prometheusQueryTimeout" configuration variable, or nil if promAPI is nil (no
+// "prometheusAddress" configured).
+func buildPrometheusGatherer(promAPI promv1.API) (prometheus.Gatherer, error) {
+	if promAPI == nil {
+		return nil, nil
+	}
+
+	queryTimeout, err := parseInt64EnvVar("prometheusQueryTimeout", defaultPrometheusQueryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prometheus query timeout provided: %v", err)
+	}
+
+	return &prometheus.Gather{
+		Client:       promAPI,
+		QueryTimeout: time.Duration(queryTimeout) * time.Second,
+	}, nil
+}
+
+// buildAnnotationInferer returns an annotation.Inferer synthesizing additional MetricSpecs from the scale
+// target's annotations, or nil if the "annotationInferenceEnabled" configuration variable isn't set to "true",
+// leaving annotation-driven inference disabled by default.
+func buildAnnotationInferer() annotation.Inferer {
+	if os.Getenv("annotationInferenceEnabled") != "true" {
+		return nil
+	}
+
+	return &annotation.Infer{
+		Prefix: os.Getenv("annotationPrefix"),
+	}
+}
+
+// buildPredictionGatherer returns a prediction.Gatherer forecasting an existing metric forward by
+// PredictionHorizon, or nil if the "predictionEnabled" configuration variable isn't set to "true", leaving
+// prediction disabled by default. metricsClient backs the current (non-predicted) value Gather forecasts from.
+func buildPredictionGatherer(metricsClient metric.MetricsClient) (prediction.Gatherer, error) {
+	if os.Getenv("predictionEnabled") != "true" {
+		return nil, nil
+	}
+
+	strategy := os.Getenv("predictionStrategy")
+	if strategy == "" {
+		strategy = defaultPredictionStrategy
+	}
+
+	predictor, err := buildPredictor(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &prediction.Gather{
+		Current:   metric.NewPredictionCurrentGetter(metricsClient),
+		Predictor: predictor,
+	}, nil
+}
+
+// buildPredictor resolves the predictionStrategy configuration value into the prediction.Predictor it names.
+func buildPredictor(strategy string) (prediction.Predictor, error) {
+	switch strategy {
+	case "holtwinters":
+		return &prediction.HoltWintersPredictor{}, nil
+	case "periodic":
+		return &prediction.PeriodicPredictor{Period: 24 * time.Hour}, nil
+	default:
+		return nil, fmt.Errorf("unknown prediction strategy %q", strategy)
+	}
+}
+
+// predictionHorizon resolves the "predictionHorizon" configuration variable (in seconds) into the Duration
+// metric.Gatherer.PredictionHorizon expects.
+func predictionHorizon() (time.Duration, error) {
+	horizon, err := parseInt64EnvVar("predictionHorizon", defaultPredictionHorizon)
+	if err != nil {
+		return 0, fmt.Errorf("invalid prediction horizon provided: %v", err)
+	}
+	return time.Duration(horizon) * time.Second, nil
+}
+
+// buildNodeGatherer returns a node.Gatherer measuring cluster-wide node resource usage, or nil if the
+// "nodeMetricsEnabled" configuration variable isn't set to "true", leaving node metrics disabled by default.
+func buildNodeGatherer(nodeMetricsClient resourceclient.NodeMetricsesGetter, clientset kubernetes.Interface) node.Gatherer {
+	if os.Getenv("nodeMetricsEnabled") != "true" {
+		return nil
+	}
+
+	return &node.Gather{
+		MetricsClient: metric.NewNodeMetricsClient(nodeMetricsClient),
+		NodeLister:    &podclient.OnDemandNodeLister{Clientset: clientset},
+	}
+}
+
 func getMetrics(stdin io.Reader) {
 	var spec MetricSpec
 	err := yaml.NewYAMLOrJSONDecoder(stdin, 10).Decode(&spec)
@@ -130,19 +370,6 @@ func getMetrics(stdin io.Reader) {
 		os.Exit(1)
 	}
 
-	// Read in metric specs to evaluate
-	var metricSpecs []autoscalingv2.MetricSpec
-	err = yaml.NewYAMLOrJSONDecoder(strings.NewReader(metricSpecsValue), 10).Decode(&metricSpecs)
-	if err != nil {
-		log.Fatal(err)
-		os.Exit(1)
-	}
-
-	if len(metricSpecs) == 0 {
-		log.Fatal("Metric specs not supplied")
-		os.Exit(1)
-	}
-
 	// Get initial readiness delay, can be set as a configuration variable
 	var initialReadinessDelay int64
 	initialReadinessDelayValue, exists := os.LookupEnv("initialReadinessDelay")
@@ -173,6 +400,21 @@ func getMetrics(stdin io.Reader) {
 		}
 	}
 
+	// Get memory initialization period, can be set as a configuration variable
+	var memoryInitializationPeriod int64
+	memoryInitializationPeriodValue, exists := os.LookupEnv("memoryInitializationPeriod")
+	if !exists {
+		// use default
+		memoryInitializationPeriod = defaultMemoryInitializationPeriod
+	} else {
+		// try to parse provided value
+		memoryInitializationPeriod, err = strconv.ParseInt(memoryInitializationPeriodValue, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid memory initialization period provided - %e\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Create the in-cluster Kubernetes config
 	clusterConfig, err := rest.InClusterConfig()
 	if err != nil {
@@ -187,26 +429,86 @@ func getMetrics(stdin io.Reader) {
 		os.Exit(1)
 	}
 
-	// Create metric gatherer, with required clients and configuration
-	gatherer := metric.NewGather(metrics.NewRESTMetricsClient(
+	// Decode the metric specs using whichever autoscaling API version this cluster's API server supports,
+	// converting v2beta2 specs up to the canonical internal.MetricSpec (see apis/internal) if needed.
+	metricSpecs, err := decodeMetricSpecs(clientset.Discovery(), metricSpecsValue)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
+	if len(metricSpecs) == 0 {
+		log.Fatal("Metric specs not supplied")
+		os.Exit(1)
+	}
+
+	scaleTargetRef, scaleObj, err := getScaleSubResource(clientset, &spec.UnstructuredResource)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
+	promAPI, err := buildPrometheusAPI(os.Getenv("prometheusAddress"))
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
+	podLister := &podclient.OnDemandPodLister{Clientset: clientset}
+	externalMetricsClient := externalclient.NewForConfigOrDie(clusterConfig)
+
+	metricsClient := buildMetricsClient(metrics.NewRESTMetricsClient(
 		resourceclient.NewForConfigOrDie(clusterConfig),
 		customclient.NewForConfig(
 			clusterConfig,
 			restmapper.NewDeferredDiscoveryRESTMapper(cacheddiscovery.NewMemCacheClient(clientset.Discovery())),
 			customclient.NewAvailableAPIsGetter(clientset.Discovery()),
 		),
-		externalclient.NewForConfigOrDie(clusterConfig),
-	), &podclient.OnDemandPodLister{Clientset: clientset}, time.Duration(cpuInitializationPeriod)*time.Second, time.Duration(initialReadinessDelay)*time.Second)
+		externalMetricsClient,
+	), promAPI)
+
+	prometheusGatherer, err := buildPrometheusGatherer(promAPI)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
+	predictionGatherer, err := buildPredictionGatherer(metricsClient)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
+	horizon, err := predictionHorizon()
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
+	// Create metric gatherer, with required clients and configuration
+	gatherer := &metric.Gatherer{
+		MetricsClient:                 metricsClient,
+		PodLister:                     podLister,
+		Prometheus:                    prometheusGatherer,
+		AnnotationInferer:             buildAnnotationInferer(),
+		External:                      external.NewGatherer(externalMetricsClient, podLister),
+		Prediction:                    predictionGatherer,
+		PredictionHorizon:             horizon,
+		Node:                          buildNodeGatherer(resourceclient.NewForConfigOrDie(clusterConfig), clientset),
+		CPUInitializationPeriod:       time.Duration(cpuInitializationPeriod) * time.Second,
+		MemoryInitializationPeriod:    time.Duration(memoryInitializationPeriod) * time.Second,
+		DelayOfInitialReadinessStatus: time.Duration(initialReadinessDelay) * time.Second,
+	}
 
 	// Get metrics for deployment
-	metrics, err := gatherer.GetMetrics(spec.Resource, metricSpecs, spec.Resource.GetNamespace())
+	gatheredMetrics, err := gatherer.GetMetrics(scaleTargetRef, scaleObj, metricSpecs, spec.Resource.GetNamespace())
 	if err != nil {
 		log.Fatal(err)
 		os.Exit(1)
 	}
 
 	// Marshal metrics into JSON
-	jsonMetrics, err := json.Marshal(metrics)
+	jsonMetrics, err := json.Marshal(gatheredMetrics)
 	if err != nil {
 		log.Fatal(err)
 		os.Exit(1)
@@ -216,6 +518,71 @@ func getMetrics(stdin io.Reader) {
 	fmt.Print(string(jsonMetrics))
 }
 
+// decodeMetricSpecs decodes the metrics env var into the canonical internal.MetricSpec type, detecting via
+// discovery whether the API server supports autoscaling/v2 and falling back to decoding and converting
+// autoscaling/v2beta2 specs (the only version available on clusters older than Kubernetes 1.26).
+func decodeMetricSpecs(disco discovery.DiscoveryInterface, rawMetricSpecs string) ([]internal.MetricSpec, error) {
+	_, err := disco.ServerResourcesForGroupVersion(autoscaling.SchemeGroupVersion.String())
+	if err == nil {
+		var metricSpecs []internal.MetricSpec
+		if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(rawMetricSpecs), 10).Decode(&metricSpecs); err != nil {
+			return nil, fmt.Errorf("failed to decode autoscaling/v2 metric specs: %v", err)
+		}
+		return metricSpecs, nil
+	}
+
+	var v2beta2MetricSpecs []autoscalingv2beta2.MetricSpec
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(rawMetricSpecs), 10).Decode(&v2beta2MetricSpecs); err != nil {
+		return nil, fmt.Errorf("failed to decode autoscaling/v2beta2 metric specs: %v", err)
+	}
+
+	metricSpecs, err := internal.FromV2Beta2(v2beta2MetricSpecs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert autoscaling/v2beta2 metric specs: %v", err)
+	}
+
+	return metricSpecs, nil
+}
+
+// getScaleSubResource resolves the scale subresource for the piped unstructured resource, returning both the
+// CrossVersionObjectReference describing it and its current Scale, as required by metric.Gatherer.GetMetrics.
+func getScaleSubResource(clientset *kubernetes.Clientset, resource *unstructured.Unstructured) (autoscaling.CrossVersionObjectReference, *autoscalingv1.Scale, error) {
+	groupResources, err := restmapper.GetAPIGroupResources(clientset.Discovery())
+	if err != nil {
+		return autoscaling.CrossVersionObjectReference{}, nil, err
+	}
+
+	scaleClient := k8sscale.New(
+		clientset.RESTClient(),
+		restmapper.NewDiscoveryRESTMapper(groupResources),
+		dynamic.LegacyAPIPathResolverFunc,
+		k8sscale.NewDiscoveryScaleKindResolver(clientset.Discovery()),
+	)
+
+	resourceGV, err := schema.ParseGroupVersion(resource.GetAPIVersion())
+	if err != nil {
+		return autoscaling.CrossVersionObjectReference{}, nil, err
+	}
+
+	targetGR := schema.GroupResource{
+		Group:    resourceGV.Group,
+		Resource: resource.GetKind(),
+	}
+
+	scaleTargetRef := autoscaling.CrossVersionObjectReference{
+		Kind:       resource.GetKind(),
+		Name:       resource.GetName(),
+		APIVersion: resource.GetAPIVersion(),
+	}
+
+	scaleObj, err := scaleClient.Scales(resource.GetNamespace()).Get(context.Background(), targetGR, resource.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return autoscaling.CrossVersionObjectReference{}, nil, err
+	}
+
+	return scaleTargetRef, scaleObj, nil
+}
+
 func getEvaluation(stdin io.Reader) {
 	var spec EvaluateSpec
 	err := yaml.NewYAMLOrJSONDecoder(stdin, 10).Decode(&spec)
@@ -255,6 +622,17 @@ func getEvaluation(stdin io.Reader) {
 		}
 	}
 
+	// Get evaluation strategy, can be set as a configuration variable
+	evaluationStrategy, exists := os.LookupEnv("evaluationStrategy")
+	if !exists {
+		evaluationStrategy = defaultEvaluationStrategy
+	}
+	aggregator, err := buildAggregator(evaluationStrategy)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
 	var combinedMetrics []*metric.Metric
 	err = yaml.NewYAMLOrJSONDecoder(strings.NewReader(spec.Metrics[0].Value), 10).Decode(&combinedMetrics)
 	if err != nil {
@@ -262,8 +640,8 @@ func getEvaluation(stdin io.Reader) {
 		os.Exit(1)
 	}
 
-	evaluator := evaluate.NewEvaluate(tolerance)
-	evaluation, err := evaluator.GetEvaluation(combinedMetrics)
+	evaluator := evaluate.NewEvaluate(tolerance, evaluate.WithAggregator(aggregator))
+	evaluation, err := evaluator.GetEvaluation(spec.Resource.GetUID(), spec.Behavior, combinedMetrics)
 	if err != nil {
 		log.Fatal(err)
 		os.Exit(1)
@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/aggregate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/external"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/node"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/podclient"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	cacheddiscovery "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+	customclient "k8s.io/metrics/pkg/client/custom_metrics"
+	externalclient "k8s.io/metrics/pkg/client/external_metrics"
+)
+
+const defaultSocket = "/tmp/hpa.sock"
+
+// gatherMetricsRequest is the payload for the server's gather metrics endpoint, mirroring the fields the metric
+// and evaluate modes read from stdin and the environment.
+type gatherMetricsRequest struct {
+	ScaleTargetRef autoscaling.CrossVersionObjectReference `json:"scaleTargetRef"`
+	Scale          autoscalingv1.Scale                     `json:"scale"`
+	MetricSpecs    []autoscaling.MetricSpec                `json:"metricSpecs"`
+	Namespace      string                                  `json:"namespace"`
+}
+
+type gatherMetricsResponse struct {
+	Metrics []*metric.CombinedMetric `json:"metrics"`
+}
+
+// getEvaluationRequest is the payload for the server's get evaluation endpoint. ResourceUID and Behavior are
+// optional, matching evaluate.Evaluate.GetEvaluation: omit both to evaluate without stabilization or scaling
+// policies.
+type getEvaluationRequest struct {
+	Metrics     []*metric.Metric                             `json:"metrics"`
+	ResourceUID types.UID                                    `json:"resourceUID,omitempty"`
+	Behavior    *autoscaling.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+type getEvaluationResponse struct {
+	Evaluation *evaluate.Evaluation `json:"evaluation"`
+}
+
+// server holds the Kubernetes clients, metric gatherer and evaluator built once at startup, reused across every
+// request rather than rebuilding them on each invocation like the metric/evaluate modes do.
+type server struct {
+	gatherer  *metric.Gatherer
+	evaluator *evaluate.Evaluate
+}
+
+// newServer builds the Kubernetes clientset, discovery cache, REST mapper, metrics client and evaluator exactly
+// once, to be reused for the lifetime of the process.
+func newServer(tolerance float64, cpuInitializationPeriod, memoryInitializationPeriod, initialReadinessDelay time.Duration, aggregator aggregate.Aggregator) (*server, error) {
+	clusterConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create in-cluster config: %v", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes clientset: %v", err)
+	}
+
+	discoveryClient := cacheddiscovery.NewMemCacheClient(clientset.Discovery())
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
+
+	promAPI, err := buildPrometheusAPI(os.Getenv("prometheusAddress"))
+	if err != nil {
+		return nil, err
+	}
+
+	podLister := &podclient.OnDemandPodLister{Clientset: clientset}
+	externalMetricsClient := externalclient.NewForConfigOrDie(clusterConfig)
+
+	metricsClient := buildMetricsClient(metrics.NewRESTMetricsClient(
+		resourceclient.NewForConfigOrDie(clusterConfig),
+		customclient.NewForConfig(
+			clusterConfig,
+			restMapper,
+			customclient.NewAvailableAPIsGetter(clientset.Discovery()),
+		),
+		externalMetricsClient,
+	), promAPI)
+
+	prometheusGatherer, err := buildPrometheusGatherer(promAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	predictionGatherer, err := buildPredictionGatherer(metricsClient)
+	if err != nil {
+		return nil, err
+	}
+
+	horizon, err := predictionHorizon()
+	if err != nil {
+		return nil, err
+	}
+
+	gatherer := &metric.Gatherer{
+		MetricsClient:                 metricsClient,
+		PodLister:                     podLister,
+		Prometheus:                    prometheusGatherer,
+		AnnotationInferer:             buildAnnotationInferer(),
+		External:                      external.NewGatherer(externalMetricsClient, podLister),
+		Prediction:                    predictionGatherer,
+		PredictionHorizon:             horizon,
+		Node:                          buildNodeGatherer(resourceclient.NewForConfigOrDie(clusterConfig), clientset),
+		CPUInitializationPeriod:       cpuInitializationPeriod,
+		MemoryInitializationPeriod:    memoryInitializationPeriod,
+		DelayOfInitialReadinessStatus: initialReadinessDelay,
+	}
+
+	return &server{
+		gatherer:  gatherer,
+		evaluator: evaluate.NewEvaluate(tolerance, evaluate.WithAggregator(aggregator)),
+	}, nil
+}
+
+// runServer starts a long-running HTTP+JSON server listening on a unix socket at socketPath, exposing
+// GatherMetrics and GetEvaluation endpoints backed by the server's shared gatherer and evaluator. This avoids the
+// per-invocation client/discovery bootstrap the metric/evaluate modes pay on every call.
+func runServer(srv *server, socketPath string) error {
+	if err := os.RemoveAll(socketPath); err != nil {
+		return fmt.Errorf("failed to clear existing socket %s: %v", socketPath, err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on socket %s: %v", socketPath, err)
+	}
+	defer listener.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gathermetrics", srv.handleGatherMetrics)
+	mux.HandleFunc("/getevaluation", srv.handleGetEvaluation)
+
+	log.Printf("server listening on unix socket %s", socketPath)
+	return http.Serve(listener, mux)
+}
+
+func (s *server) handleGatherMetrics(w http.ResponseWriter, r *http.Request) {
+	var req gatherMetricsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := labels.Parse(req.Scale.Status.Selector); err != nil {
+		http.Error(w, fmt.Sprintf("invalid scale subresource selector: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	metrics, err := s.gatherer.GetMetrics(req.ScaleTargetRef, &req.Scale, req.MetricSpecs, req.Namespace)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to gather metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, gatherMetricsResponse{Metrics: metrics})
+}
+
+func (s *server) handleGetEvaluation(w http.ResponseWriter, r *http.Request) {
+	var req getEvaluationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	evaluation, err := s.evaluator.GetEvaluation(req.ResourceUID, req.Behavior, req.Metrics)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get evaluation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, getEvaluationResponse{Evaluation: evaluation})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package aggregate provides pluggable strategies for combining the per-metric evaluations a HorizontalPodAutoscaler
+// produces into a single replica recommendation, used by evaluate.Evaluate in place of the classic HPA's
+// hard-coded "highest replica count wins" rule.
+package aggregate
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Aggregator combines the evaluations proposed by each successfully evaluated metric into a single evaluation.
+// proposals is never empty; evaluate.Evaluate filters out metrics that failed to evaluate before calling Combine,
+// and returns their error directly rather than aggregating if every metric failed.
+type Aggregator interface {
+	Combine(proposals []*evaluate.Evaluation) *evaluate.Evaluation
+}
+
+// Max takes the highest proposed replica count, matching the classic HPA's behavior of always scaling to satisfy
+// whichever metric demands the most replicas.
+type Max struct{}
+
+// Combine returns the proposal with the highest TargetReplicas.
+func (Max) Combine(proposals []*evaluate.Evaluation) *evaluate.Evaluation {
+	result := proposals[0]
+	for _, proposal := range proposals[1:] {
+		if proposal.TargetReplicas > result.TargetReplicas {
+			result = proposal
+		}
+	}
+	return result
+}
+
+// Min takes the lowest proposed replica count.
+type Min struct{}
+
+// Combine returns the proposal with the lowest TargetReplicas.
+func (Min) Combine(proposals []*evaluate.Evaluation) *evaluate.Evaluation {
+	result := proposals[0]
+	for _, proposal := range proposals[1:] {
+		if proposal.TargetReplicas < result.TargetReplicas {
+			result = proposal
+		}
+	}
+	return result
+}
+
+// Mean takes the average of the proposed replica counts, rounded up.
+type Mean struct{}
+
+// Combine returns the ceiling of the mean of every proposal's TargetReplicas.
+func (Mean) Combine(proposals []*evaluate.Evaluation) *evaluate.Evaluation {
+	total := int64(0)
+	for _, proposal := range proposals {
+		total += int64(proposal.TargetReplicas)
+	}
+	mean := float64(total) / float64(len(proposals))
+	return &evaluate.Evaluation{TargetReplicas: int32(math.Ceil(mean))}
+}
+
+// Median takes the middle proposed replica count, or the ceiling of the average of the two middle values when
+// there's an even number of proposals.
+type Median struct{}
+
+// Combine returns the median of every proposal's TargetReplicas.
+func (Median) Combine(proposals []*evaluate.Evaluation) *evaluate.Evaluation {
+	replicas := make([]int32, len(proposals))
+	for i, proposal := range proposals {
+		replicas[i] = proposal.TargetReplicas
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i] < replicas[j] })
+
+	mid := len(replicas) / 2
+	if len(replicas)%2 == 1 {
+		return &evaluate.Evaluation{TargetReplicas: replicas[mid]}
+	}
+	average := float64(replicas[mid-1]+replicas[mid]) / 2
+	return &evaluate.Evaluation{TargetReplicas: int32(math.Ceil(average))}
+}
+
+// WeightLabel is the MatchLabels key a MetricSpec's Metric.Selector can carry to set that metric's weight for the
+// Weighted Aggregator, following the same convention the External and Object evaluators' per-metric tolerance
+// override uses to carry extra configuration without extending the upstream MetricSpec type.
+const WeightLabel = "weight"
+
+// MetricWeight reads the weight spec carries under WeightLabel, for whichever of Object, Pods or External holds
+// its Metric.Selector (Resource and ContainerResource metrics have no such slot). It returns 1, the neutral
+// weight, if spec has no selector, no WeightLabel, or an invalid (non-positive, non-integer) value.
+func MetricWeight(spec autoscaling.MetricSpec) int32 {
+	var selector *metav1.LabelSelector
+	switch spec.Type {
+	case autoscaling.ObjectMetricSourceType:
+		if spec.Object != nil {
+			selector = spec.Object.Metric.Selector
+		}
+	case autoscaling.PodsMetricSourceType:
+		if spec.Pods != nil {
+			selector = spec.Pods.Metric.Selector
+		}
+	case autoscaling.ExternalMetricSourceType:
+		if spec.External != nil {
+			selector = spec.External.Metric.Selector
+		}
+	}
+	if selector == nil {
+		return 1
+	}
+
+	raw, ok := selector.MatchLabels[WeightLabel]
+	if !ok {
+		return 1
+	}
+	weight, err := strconv.Atoi(raw)
+	if err != nil || weight <= 0 {
+		return 1
+	}
+	return int32(weight)
+}
+
+// Weighted combines proposals into their weighted average, rounded up. Weights is aligned positionally with the
+// proposals Combine is called with; a proposal with no corresponding entry in Weights, or a non-positive one,
+// falls back to the neutral weight of 1 rather than being excluded.
+type Weighted struct {
+	Weights []int32
+}
+
+// Combine returns the ceiling of the weighted mean of every proposal's TargetReplicas.
+func (w Weighted) Combine(proposals []*evaluate.Evaluation) *evaluate.Evaluation {
+	var weightedTotal, totalWeight int64
+	for i, proposal := range proposals {
+		weight := int32(1)
+		if i < len(w.Weights) && w.Weights[i] > 0 {
+			weight = w.Weights[i]
+		}
+		weightedTotal += int64(proposal.TargetReplicas) * int64(weight)
+		totalWeight += int64(weight)
+	}
+	return &evaluate.Evaluation{TargetReplicas: int32(math.Ceil(float64(weightedTotal) / float64(totalWeight)))}
+}
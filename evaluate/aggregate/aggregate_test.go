@@ -0,0 +1,206 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aggregate_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/aggregate"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func proposals(replicas ...int32) []*evaluate.Evaluation {
+	result := make([]*evaluate.Evaluation, len(replicas))
+	for i, r := range replicas {
+		result[i] = &evaluate.Evaluation{TargetReplicas: r}
+	}
+	return result
+}
+
+func TestMax(t *testing.T) {
+	evaluation := aggregate.Max{}.Combine(proposals(3, 10, 7))
+
+	expected := &evaluate.Evaluation{TargetReplicas: 10}
+	if !cmp.Equal(expected, evaluation) {
+		t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+	}
+}
+
+func TestMin(t *testing.T) {
+	evaluation := aggregate.Min{}.Combine(proposals(3, 10, 7))
+
+	expected := &evaluate.Evaluation{TargetReplicas: 3}
+	if !cmp.Equal(expected, evaluation) {
+		t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+	}
+}
+
+func TestMean(t *testing.T) {
+	evaluation := aggregate.Mean{}.Combine(proposals(3, 10, 7))
+
+	// (3 + 10 + 7) / 3 = 6.67, rounded up to 7.
+	expected := &evaluate.Evaluation{TargetReplicas: 7}
+	if !cmp.Equal(expected, evaluation) {
+		t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+	}
+}
+
+func TestMedian(t *testing.T) {
+	t.Run("odd number of proposals takes the middle value", func(t *testing.T) {
+		evaluation := aggregate.Median{}.Combine(proposals(10, 3, 7))
+
+		expected := &evaluate.Evaluation{TargetReplicas: 7}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("even number of proposals takes the ceiling of the two middle values' average", func(t *testing.T) {
+		evaluation := aggregate.Median{}.Combine(proposals(10, 3, 7, 4))
+
+		// sorted: 3, 4, 7, 10 -> (4 + 7) / 2 = 5.5, rounded up to 6.
+		expected := &evaluate.Evaluation{TargetReplicas: 6}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+}
+
+func TestWeighted(t *testing.T) {
+	t.Run("combines proposals using their aligned weights", func(t *testing.T) {
+		weighted := aggregate.Weighted{Weights: []int32{1, 3}}
+		evaluation := weighted.Combine(proposals(2, 10))
+
+		// (2*1 + 10*3) / (1 + 3) = 8, exact.
+		expected := &evaluate.Evaluation{TargetReplicas: 8}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("missing or non-positive weights fall back to the neutral weight of 1", func(t *testing.T) {
+		weighted := aggregate.Weighted{Weights: []int32{0}}
+		evaluation := weighted.Combine(proposals(2, 10))
+
+		// (2*1 + 10*1) / (1 + 1) = 6, exact.
+		expected := &evaluate.Evaluation{TargetReplicas: 6}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+}
+
+func TestMetricWeight(t *testing.T) {
+	equalsSelector := func(weight string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{aggregate.WeightLabel: weight}}
+	}
+
+	tests := []struct {
+		description string
+		spec        autoscaling.MetricSpec
+		expected    int32
+	}{
+		{
+			description: "object metric with a valid weight",
+			spec: autoscaling.MetricSpec{
+				Type: autoscaling.ObjectMetricSourceType,
+				Object: &autoscaling.ObjectMetricSource{
+					Metric: autoscaling.MetricIdentifier{Selector: equalsSelector("5")},
+				},
+			},
+			expected: 5,
+		},
+		{
+			description: "pods metric with a valid weight",
+			spec: autoscaling.MetricSpec{
+				Type: autoscaling.PodsMetricSourceType,
+				Pods: &autoscaling.PodsMetricSource{
+					Metric: autoscaling.MetricIdentifier{Selector: equalsSelector("2")},
+				},
+			},
+			expected: 2,
+		},
+		{
+			description: "external metric with a valid weight",
+			spec: autoscaling.MetricSpec{
+				Type: autoscaling.ExternalMetricSourceType,
+				External: &autoscaling.ExternalMetricSource{
+					Metric: autoscaling.MetricIdentifier{Selector: equalsSelector("9")},
+				},
+			},
+			expected: 9,
+		},
+		{
+			description: "resource metric has no selector slot, defaults to 1",
+			spec: autoscaling.MetricSpec{
+				Type:     autoscaling.ResourceMetricSourceType,
+				Resource: &autoscaling.ResourceMetricSource{},
+			},
+			expected: 1,
+		},
+		{
+			description: "no selector defaults to 1",
+			spec: autoscaling.MetricSpec{
+				Type:   autoscaling.ObjectMetricSourceType,
+				Object: &autoscaling.ObjectMetricSource{},
+			},
+			expected: 1,
+		},
+		{
+			description: "no weight label defaults to 1",
+			spec: autoscaling.MetricSpec{
+				Type: autoscaling.ObjectMetricSourceType,
+				Object: &autoscaling.ObjectMetricSource{
+					Metric: autoscaling.MetricIdentifier{Selector: &metav1.LabelSelector{}},
+				},
+			},
+			expected: 1,
+		},
+		{
+			description: "non-integer weight defaults to 1",
+			spec: autoscaling.MetricSpec{
+				Type: autoscaling.ObjectMetricSourceType,
+				Object: &autoscaling.ObjectMetricSource{
+					Metric: autoscaling.MetricIdentifier{Selector: equalsSelector("not-a-number")},
+				},
+			},
+			expected: 1,
+		},
+		{
+			description: "non-positive weight defaults to 1",
+			spec: autoscaling.MetricSpec{
+				Type: autoscaling.ObjectMetricSourceType,
+				Object: &autoscaling.ObjectMetricSource{
+					Metric: autoscaling.MetricIdentifier{Selector: equalsSelector("0")},
+				},
+			},
+			expected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			weight := aggregate.MetricWeight(test.spec)
+			if weight != test.expected {
+				t.Errorf("weight mismatch, expected %d, got %d", test.expected, weight)
+			}
+		})
+	}
+}
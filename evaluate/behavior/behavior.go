@@ -0,0 +1,392 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package behavior wraps an Evaluator with the HPA v2 HorizontalPodAutoscalerBehavior: a per-direction
+// stabilization window followed by a per-direction cap built from HPAScalingPolicy entries, combined using the
+// direction's SelectPolicy.
+package behavior
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Evaluator (behavior) produces an evaluation based on a metric provided, matching the signature shared by the
+// object, resource, containerresource and external evaluators.
+type Evaluator interface {
+	GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
+}
+
+// Recommendation is a single raw recommendation retained in a Recommender's history, used both to drive
+// stabilization windowing and to let callers inspect what was actually recommended before policies capped it.
+// CurrentReplicas is the replica count in effect when the recommendation was made, letting a later call within the
+// same scaling policy period work out how much of that period's budget has already been spent.
+type Recommendation struct {
+	Timestamp       time.Time
+	TargetReplicas  int32
+	CurrentReplicas int32
+}
+
+// Recommender stores and retrieves the recommendation history a Behavior-wrapped Evaluate consults, allowing the
+// backing storage to be swapped out (for example for a ConfigMap) so history survives a controller restart rather
+// than only living in memory.
+type Recommender interface {
+	Load() ([]Recommendation, error)
+	Save(recommendations []Recommendation) error
+}
+
+// MemoryRecommender is the default Recommender, keeping recommendation history in memory for the lifetime of the
+// process. It is safe for concurrent use.
+type MemoryRecommender struct {
+	mu              sync.Mutex
+	recommendations []Recommendation
+}
+
+// Load returns the recommendation history currently held in memory.
+func (m *MemoryRecommender) Load() ([]Recommendation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Recommendation(nil), m.recommendations...), nil
+}
+
+// Save replaces the recommendation history currently held in memory.
+func (m *MemoryRecommender) Save(recommendations []Recommendation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recommendations = recommendations
+	return nil
+}
+
+// RecommenderStore resolves the Recommender to use for a specific target resource, identified by UID, so that a
+// single long-lived Evaluate (such as the one the server command keeps for the lifetime of the process) can
+// evaluate many HorizontalPodAutoscalers while keeping each one's stabilization window independent.
+type RecommenderStore interface {
+	Get(uid types.UID) Recommender
+}
+
+// MemoryRecommenderStore is the default RecommenderStore, handing out one in-memory Recommender per UID for the
+// lifetime of the process. It is safe for concurrent use.
+type MemoryRecommenderStore struct {
+	mu           sync.Mutex
+	recommenders map[types.UID]*MemoryRecommender
+}
+
+// Get returns the Recommender for uid, creating one the first time uid is seen.
+func (m *MemoryRecommenderStore) Get(uid types.UID) Recommender {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.recommenders == nil {
+		m.recommenders = make(map[types.UID]*MemoryRecommender)
+	}
+	recommender, ok := m.recommenders[uid]
+	if !ok {
+		recommender = &MemoryRecommender{}
+		m.recommenders[uid] = recommender
+	}
+	return recommender
+}
+
+// Equal reports whether m and other hold the same recommendation history, letting cmp-based tests compare an
+// Evaluate containing a MemoryRecommenderStore without reaching into its unexported fields directly.
+func (m *MemoryRecommenderStore) Equal(other *MemoryRecommenderStore) bool {
+	return reflect.DeepEqual(m.recommenders, other.recommenders)
+}
+
+// Evaluate (behavior) wraps another Evaluator, applying the v2 HorizontalPodAutoscalerBehavior on top of its raw
+// recommendation: stabilization first (the highest recommendation within the scale-down window, or the lowest
+// within the scale-up window), then a per-direction cap built from the direction's HPAScalingPolicy entries. A nil
+// Behavior, or a direction with no Policies, leaves that part of the calculation as a no-op, except that a
+// direction whose SelectPolicy is Disabled always keeps currentReplicas.
+type Evaluate struct {
+	Evaluator   Evaluator
+	Recommender Recommender
+	Behavior    *autoscaling.HorizontalPodAutoscalerBehavior
+}
+
+// NewEvaluate sets up a behavior.Evaluate wrapping the provided Evaluator, backed by an in-memory Recommender.
+func NewEvaluate(evaluator Evaluator, behavior *autoscaling.HorizontalPodAutoscalerBehavior) *Evaluate {
+	return &Evaluate{
+		Evaluator:   evaluator,
+		Recommender: &MemoryRecommender{},
+		Behavior:    behavior,
+	}
+}
+
+// GetEvaluation calculates the raw recommendation using the wrapped Evaluator, records it in the recommendation
+// history, stabilizes it over the relevant direction's window, then caps the result using that direction's scaling
+// policies. The raw, pre-policy recommendation remains available to callers through the Recommender, so it can be
+// persisted alongside the final evaluation.
+func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	evaluation, err := e.Evaluator.GetEvaluation(currentReplicas, gatheredMetric)
+	if err != nil {
+		return nil, err
+	}
+
+	limited, err := Apply(e.Recommender, e.Behavior, currentReplicas, evaluation.TargetReplicas)
+	if err != nil {
+		return nil, err
+	}
+
+	return &evaluate.Evaluation{
+		TargetReplicas: limited,
+	}, nil
+}
+
+// Apply stabilizes and rate-limits proposed using behaviorRules, consulting and updating the recommendation
+// history held in recommender. It is the part of GetEvaluation that doesn't depend on wrapping an Evaluator, so
+// callers that already have a raw recommendation in hand (for example evaluate.Evaluate, which combines several
+// metrics' evaluations before a single Behavior applies) can reuse it directly. A nil behaviorRules, or one with
+// no rules for the relevant direction, leaves proposed unchanged apart from being recorded in the history.
+func Apply(recommender Recommender, behaviorRules *autoscaling.HorizontalPodAutoscalerBehavior, currentReplicas, proposed int32) (int32, error) {
+	e := &Evaluate{Recommender: recommender, Behavior: behaviorRules}
+
+	recommendations, err := e.Recommender.Load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load behavior recommendation history: %v", err)
+	}
+
+	now := time.Now()
+	recommendations = append(recommendations, Recommendation{
+		Timestamp:       now,
+		TargetReplicas:  proposed,
+		CurrentReplicas: currentReplicas,
+	})
+
+	stabilized := e.stabilize(recommendations, now, currentReplicas, proposed)
+	limited := e.limit(recommendations, now, stabilized, currentReplicas)
+
+	retention := stabilizationWindow(e.scaleUpRules())
+	if downWindow := stabilizationWindow(e.scaleDownRules()); downWindow > retention {
+		retention = downWindow
+	}
+	if upPeriod := longestPeriod(e.scaleUpRules()); upPeriod > retention {
+		retention = upPeriod
+	}
+	if downPeriod := longestPeriod(e.scaleDownRules()); downPeriod > retention {
+		retention = downPeriod
+	}
+	recommendations = withinWindow(recommendations, now, retention)
+
+	if err := e.Recommender.Save(recommendations); err != nil {
+		return 0, fmt.Errorf("failed to save behavior recommendation history: %v", err)
+	}
+
+	return limited, nil
+}
+
+// stabilize picks the least aggressive recommendation within the relevant direction's stabilization window: the
+// highest seen if proposed is a scale down, or the lowest seen if proposed is a scale up. A direction with no
+// window configured (or no Behavior at all) leaves proposed unchanged.
+func (e *Evaluate) stabilize(recommendations []Recommendation, now time.Time, currentReplicas, proposed int32) int32 {
+	switch {
+	case proposed < currentReplicas:
+		window := stabilizationWindow(e.scaleDownRules())
+		if window <= 0 {
+			return proposed
+		}
+		return max(withinWindow(recommendations, now, window))
+	case proposed > currentReplicas:
+		window := stabilizationWindow(e.scaleUpRules())
+		if window <= 0 {
+			return proposed
+		}
+		return min(withinWindow(recommendations, now, window))
+	default:
+		return proposed
+	}
+}
+
+// limit caps proposed using the relevant direction's scaling policies, leaving it unchanged if there is no
+// Behavior, or the direction has no Policies configured and a SelectPolicy other than Disabled (Disabled always
+// keeps currentReplicas). recommendations and now let each policy bound the
+// cumulative change over its own PeriodSeconds rather than just a single step off currentReplicas.
+func (e *Evaluate) limit(recommendations []Recommendation, now time.Time, proposed, currentReplicas int32) int32 {
+	switch {
+	case proposed > currentReplicas:
+		return limitDirection(recommendations, now, e.scaleUpRules(), proposed, currentReplicas, true)
+	case proposed < currentReplicas:
+		return limitDirection(recommendations, now, e.scaleDownRules(), proposed, currentReplicas, false)
+	default:
+		return proposed
+	}
+}
+
+func limitDirection(recommendations []Recommendation, now time.Time, rules *autoscaling.HPAScalingRules, proposed, currentReplicas int32, up bool) int32 {
+	if rules == nil {
+		return proposed
+	}
+
+	if rules.SelectPolicy != nil && *rules.SelectPolicy == autoscaling.DisabledPolicySelect {
+		return currentReplicas
+	}
+
+	if len(rules.Policies) == 0 {
+		return proposed
+	}
+
+	selectMin := rules.SelectPolicy != nil && *rules.SelectPolicy == autoscaling.MinPolicySelect
+
+	var limit int32
+	if selectMin {
+		limit = math.MaxInt32
+	} else {
+		limit = math.MinInt32
+	}
+
+	for _, policy := range rules.Policies {
+		periodStart := periodStartReplicas(recommendations, now, policy.PeriodSeconds, currentReplicas)
+		candidate, ok := candidateReplicas(policy, periodStart, up)
+		if !ok {
+			continue
+		}
+		if selectMin && candidate < limit {
+			limit = candidate
+		} else if !selectMin && candidate > limit {
+			limit = candidate
+		}
+	}
+
+	if up {
+		if proposed > limit {
+			return limit
+		}
+		return proposed
+	}
+	if proposed < limit {
+		return limit
+	}
+	return proposed
+}
+
+// periodStartReplicas returns the replica count in effect at the start of a policy's PeriodSeconds window, so
+// candidateReplicas can bound the cumulative change across every call within that window instead of handing out a
+// fresh single-step delta off the live currentReplicas on every reconcile. It's the CurrentReplicas recorded
+// alongside the earliest prior recommendation within the window, or currentReplicas itself if there is no earlier
+// recommendation in the window (nothing has spent the period's budget yet).
+func periodStartReplicas(recommendations []Recommendation, now time.Time, periodSeconds int32, currentReplicas int32) int32 {
+	period := time.Duration(periodSeconds) * time.Second
+	result := currentReplicas
+	var earliest time.Time
+	found := false
+	for _, recommendation := range recommendations {
+		if !recommendation.Timestamp.Before(now) {
+			// this call's own just-recorded proposal, not a prior one
+			continue
+		}
+		if now.Sub(recommendation.Timestamp) > period {
+			continue
+		}
+		if !found || recommendation.Timestamp.Before(earliest) {
+			earliest = recommendation.Timestamp
+			result = recommendation.CurrentReplicas
+			found = true
+		}
+	}
+	return result
+}
+
+func candidateReplicas(policy autoscaling.HPAScalingPolicy, periodStartReplicas int32, up bool) (int32, bool) {
+	switch policy.Type {
+	case autoscaling.PodsScalingPolicy:
+		if up {
+			return periodStartReplicas + policy.Value, true
+		}
+		return periodStartReplicas - policy.Value, true
+	case autoscaling.PercentScalingPolicy:
+		percent := float64(policy.Value) / 100
+		if up {
+			return int32(math.Ceil(float64(periodStartReplicas) * (1 + percent))), true
+		}
+		return int32(math.Floor(float64(periodStartReplicas) * (1 - percent))), true
+	default:
+		return 0, false
+	}
+}
+
+func (e *Evaluate) scaleUpRules() *autoscaling.HPAScalingRules {
+	if e.Behavior == nil {
+		return nil
+	}
+	return e.Behavior.ScaleUp
+}
+
+func (e *Evaluate) scaleDownRules() *autoscaling.HPAScalingRules {
+	if e.Behavior == nil {
+		return nil
+	}
+	return e.Behavior.ScaleDown
+}
+
+func stabilizationWindow(rules *autoscaling.HPAScalingRules) time.Duration {
+	if rules == nil || rules.StabilizationWindowSeconds == nil {
+		return 0
+	}
+	return time.Duration(*rules.StabilizationWindowSeconds) * time.Second
+}
+
+// longestPeriod returns the longest PeriodSeconds among rules' Policies, so the recommendation history is retained
+// for at least as long as the slowest policy needs to look back for its periodStartReplicas calculation.
+func longestPeriod(rules *autoscaling.HPAScalingRules) time.Duration {
+	if rules == nil {
+		return 0
+	}
+	var longest time.Duration
+	for _, policy := range rules.Policies {
+		if period := time.Duration(policy.PeriodSeconds) * time.Second; period > longest {
+			longest = period
+		}
+	}
+	return longest
+}
+
+// withinWindow returns the recommendations with a timestamp no older than window before now.
+func withinWindow(recommendations []Recommendation, now time.Time, window time.Duration) []Recommendation {
+	kept := make([]Recommendation, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		if now.Sub(recommendation.Timestamp) <= window {
+			kept = append(kept, recommendation)
+		}
+	}
+	return kept
+}
+
+func max(recommendations []Recommendation) int32 {
+	result := recommendations[0].TargetReplicas
+	for _, recommendation := range recommendations[1:] {
+		if recommendation.TargetReplicas > result {
+			result = recommendation.TargetReplicas
+		}
+	}
+	return result
+}
+
+func min(recommendations []Recommendation) int32 {
+	result := recommendations[0].TargetReplicas
+	for _, recommendation := range recommendations[1:] {
+		if recommendation.TargetReplicas < result {
+			result = recommendation.TargetReplicas
+		}
+	}
+	return result
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package behavior_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/behavior"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	autoscaling "k8s.io/api/autoscaling/v2"
+)
+
+type fakeEvaluator struct {
+	targetReplicas int32
+}
+
+func (f *fakeEvaluator) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	return &evaluate.Evaluation{TargetReplicas: f.targetReplicas}, nil
+}
+
+func selectPolicy(s autoscaling.ScalingPolicySelect) *autoscaling.ScalingPolicySelect {
+	return &s
+}
+
+func seconds(s int32) *int32 {
+	return &s
+}
+
+func TestGetEvaluation(t *testing.T) {
+	t.Run("nil behavior leaves the raw recommendation untouched", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 10}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, nil)
+
+		evaluation, err := behaviorEvaluate.GetEvaluation(4, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 10}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("scale up stabilization suppresses flapping by using the lowest recommendation in the window", func(t *testing.T) {
+		evaluator := &fakeEvaluator{}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleUp: &autoscaling.HPAScalingRules{
+				StabilizationWindowSeconds: seconds(60),
+			},
+		})
+
+		evaluator.targetReplicas = 12
+		if _, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		evaluator.targetReplicas = 20
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 12}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("scale down stabilization suppresses flapping by using the highest recommendation in the window", func(t *testing.T) {
+		evaluator := &fakeEvaluator{}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleDown: &autoscaling.HPAScalingRules{
+				StabilizationWindowSeconds: seconds(60),
+			},
+		})
+
+		evaluator.targetReplicas = 8
+		if _, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		evaluator.targetReplicas = 3
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 8}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("scale up Pods policy rate limits the increase", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 20}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleUp: &autoscaling.HPAScalingRules{
+				Policies: []autoscaling.HPAScalingPolicy{
+					{Type: autoscaling.PodsScalingPolicy, Value: 2, PeriodSeconds: 60},
+				},
+			},
+		})
+
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 12}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("scale down Percent policy rate limits the decrease", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 1}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleDown: &autoscaling.HPAScalingRules{
+				Policies: []autoscaling.HPAScalingPolicy{
+					{Type: autoscaling.PercentScalingPolicy, Value: 50, PeriodSeconds: 60},
+				},
+			},
+		})
+
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 5}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("Max SelectPolicy takes the most permissive policy", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 100}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleUp: &autoscaling.HPAScalingRules{
+				SelectPolicy: selectPolicy(autoscaling.MaxPolicySelect),
+				Policies: []autoscaling.HPAScalingPolicy{
+					{Type: autoscaling.PodsScalingPolicy, Value: 2, PeriodSeconds: 60},
+					{Type: autoscaling.PercentScalingPolicy, Value: 100, PeriodSeconds: 60},
+				},
+			},
+		})
+
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 20}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("Min SelectPolicy takes the most restrictive policy", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 100}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleUp: &autoscaling.HPAScalingRules{
+				SelectPolicy: selectPolicy(autoscaling.MinPolicySelect),
+				Policies: []autoscaling.HPAScalingPolicy{
+					{Type: autoscaling.PodsScalingPolicy, Value: 2, PeriodSeconds: 60},
+					{Type: autoscaling.PercentScalingPolicy, Value: 100, PeriodSeconds: 60},
+				},
+			},
+		})
+
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 12}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("Disabled SelectPolicy blocks scaling in that direction", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 1}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleDown: &autoscaling.HPAScalingRules{
+				SelectPolicy: selectPolicy(autoscaling.DisabledPolicySelect),
+			},
+		})
+
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 10}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("scale up Pods policy caps cumulative growth across repeated calls within one period", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 100}
+		behaviorEvaluate := behavior.NewEvaluate(evaluator, &autoscaling.HorizontalPodAutoscalerBehavior{
+			ScaleUp: &autoscaling.HPAScalingRules{
+				Policies: []autoscaling.HPAScalingPolicy{
+					{Type: autoscaling.PodsScalingPolicy, Value: 4, PeriodSeconds: 60},
+				},
+			},
+		})
+
+		currentReplicas := int32(10)
+		for i := 0; i < 4; i++ {
+			evaluation, err := behaviorEvaluate.GetEvaluation(currentReplicas, &metric.Metric{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			currentReplicas = evaluation.TargetReplicas
+		}
+
+		// A controller reconciling several times within one 60s period should never grow replicas by more than the
+		// policy's Value across the whole period, not Value per reconcile.
+		expected := int32(14)
+		if currentReplicas != expected {
+			t.Errorf("cumulative replicas mismatch: want %d, got %d", expected, currentReplicas)
+		}
+	})
+
+	t.Run("recommendations older than the retention window are evicted", func(t *testing.T) {
+		evaluator := &fakeEvaluator{targetReplicas: 8}
+		recommender := &behavior.MemoryRecommender{}
+		behaviorEvaluate := &behavior.Evaluate{
+			Evaluator:   evaluator,
+			Recommender: recommender,
+			Behavior: &autoscaling.HorizontalPodAutoscalerBehavior{
+				ScaleDown: &autoscaling.HPAScalingRules{
+					StabilizationWindowSeconds: seconds(60),
+				},
+			},
+		}
+
+		stale := []behavior.Recommendation{
+			{Timestamp: time.Now().Add(-time.Hour), TargetReplicas: 100},
+		}
+		if err := recommender.Save(stale); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		evaluation, err := behaviorEvaluate.GetEvaluation(10, &metric.Metric{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 8}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+}
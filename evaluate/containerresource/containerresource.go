@@ -0,0 +1,136 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+Modifications Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+
+Modified to split up evaluations and metric gathering to work with the
+Custom Pod Autoscaler framework.
+Original source:
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/horizontal.go
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/replica_calculator.go
+*/
+
+package containerresource
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+)
+
+// Evaluator (containerresource) produces an evaluation based on a container resource metric provided
+type Evaluator interface {
+	GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
+}
+
+// Evaluate (containerresource) calculates a replica count evaluation, using the tolerance and calculater provided.
+// It mirrors resource.Evaluate, the only difference being that the metric and requests it is given are already
+// scoped to a single named container, rather than summed across every container in the pod.
+type Evaluate struct {
+	Calculater calculate.Calculater
+	Tolerance  float64
+	limit.Limiter
+}
+
+// GetEvaluation calculates an evaluation based on the metric provided and the current number of replicas
+func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	if gatheredMetric.Spec.ContainerResource.Target.AverageValue != nil {
+		replicaCount := e.Calculater.GetPlainMetricReplicaCount(
+			gatheredMetric.ContainerResource.PodMetricsInfo,
+			currentReplicas,
+			gatheredMetric.Spec.ContainerResource.Target.AverageValue.MilliValue(),
+			gatheredMetric.ContainerResource.ReadyPodCount,
+			gatheredMetric.ContainerResource.MissingPods,
+			gatheredMetric.ContainerResource.IgnoredPods,
+		)
+		return &evaluate.Evaluation{
+			TargetReplicas: e.Limit(currentReplicas, replicaCount),
+		}, nil
+	}
+
+	if gatheredMetric.Spec.ContainerResource.Target.AverageUtilization != nil {
+		metrics := gatheredMetric.ContainerResource.PodMetricsInfo
+		requests := gatheredMetric.ContainerResource.Requests
+		targetUtilization := *gatheredMetric.Spec.ContainerResource.Target.AverageUtilization
+		ignoredPods := gatheredMetric.ContainerResource.IgnoredPods
+		missingPods := gatheredMetric.ContainerResource.MissingPods
+		readyPodCount := gatheredMetric.ContainerResource.ReadyPodCount
+
+		usageRatio, _, _, err := metricsclient.GetResourceUtilizationRatio(metrics, requests, targetUtilization)
+		if err != nil {
+			return nil, err
+		}
+
+		rebalanceIgnored := len(ignoredPods) > 0 && usageRatio > 1.0
+		if !rebalanceIgnored && len(missingPods) == 0 {
+			if math.Abs(1.0-usageRatio) <= e.Tolerance {
+				return &evaluate.Evaluation{
+					TargetReplicas: currentReplicas,
+				}, nil
+			}
+			targetReplicas := int32(math.Ceil(usageRatio * float64(readyPodCount)))
+			return &evaluate.Evaluation{
+				TargetReplicas: e.Limit(currentReplicas, targetReplicas),
+			}, nil
+		}
+
+		if len(missingPods) > 0 {
+			if usageRatio < 1.0 {
+				// on a scale-down, treat missing pods as using 100% of the container's resource request
+				for podName := range missingPods {
+					metrics[podName] = metricsclient.PodMetric{Value: requests[podName]}
+				}
+			} else if usageRatio > 1.0 {
+				// on a scale-up, treat missing pods as using 0% of the container's resource request
+				for podName := range missingPods {
+					metrics[podName] = metricsclient.PodMetric{Value: 0}
+				}
+			}
+		}
+
+		if rebalanceIgnored {
+			// on a scale-up, treat unready pods as using 0% of the container's resource request
+			for podName := range ignoredPods {
+				metrics[podName] = metricsclient.PodMetric{Value: 0}
+			}
+		}
+
+		newUsageRatio, _, _, err := metricsclient.GetResourceUtilizationRatio(metrics, requests, targetUtilization)
+		if err != nil {
+			return nil, err
+		}
+
+		if math.Abs(1.0-newUsageRatio) <= e.Tolerance || (usageRatio < 1.0 && newUsageRatio > 1.0) || (usageRatio > 1.0 && newUsageRatio < 1.0) {
+			return &evaluate.Evaluation{
+				TargetReplicas: currentReplicas,
+			}, nil
+		}
+
+		targetReplicas := int32(math.Ceil(newUsageRatio * float64(len(metrics))))
+		return &evaluate.Evaluation{
+			TargetReplicas: e.Limit(currentReplicas, targetReplicas),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid container resource metric source: neither a utilization target nor a value target was set")
+}
@@ -33,13 +33,20 @@ import (
 	"fmt"
 
 	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/aggregate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/behavior"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/containerresource"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/external"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/object"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/pods"
+	prometheuseval "github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/prometheus"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/resource"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
-	autoscaling "k8s.io/api/autoscaling/v2beta2"
+	metricprometheus "github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // Evaluater is used to take metrics of any type and produce a single evaluation
@@ -53,35 +60,133 @@ type Evaluate struct {
 	Object   object.Evaluator
 	Pods     pods.Evaluator
 	Resource resource.Evaluator
+	// ContainerResource evaluates ContainerResourceMetricSourceType metrics, mirroring Resource but scoped to a
+	// single named container's usage and requests rather than the whole pod, so a sidecar can be scaled on
+	// independently of the rest of the pod.
+	ContainerResource containerresource.Evaluator
+	// Prometheus handles MetricSpecs with Type metric/prometheus.MetricSourceType, a synthetic metric source
+	// carrying a PromQL query evaluated directly against Prometheus.
+	Prometheus prometheuseval.Evaluator
+	// MinReplicas floors the final combined evaluation, preserving the classic HPA assumption that an evaluation
+	// never recommends fewer replicas than this. NewEvaluate defaults it to 1; set it to 0 to opt in to
+	// zero-replica recommendations, needed for scale-to-zero evaluators such as evaluate/external/grpc.
+	MinReplicas int32
+	// Recommenders resolves the recommendation history behind GetEvaluation's optional HorizontalPodAutoscalerBehavior
+	// argument, keyed by the target resource's UID. NewEvaluate defaults it to an in-memory store; swap it out for
+	// one backed by a ConfigMap to keep stabilization windows intact across restarts.
+	Recommenders behavior.RecommenderStore
+	// Aggregator combines the evaluations proposed by each successfully evaluated metric into the single
+	// evaluation GetEvaluation returns, replacing the classic HPA's hard-coded "highest replica count wins" rule
+	// with a pluggable strategy. NewEvaluate defaults it to aggregate.Max, matching that classic behavior.
+	Aggregator aggregate.Aggregator
 }
 
-// NewEvaluate sets up an evaluate that can process external, object, pod and resource metrics, with a shared replica calculater
-func NewEvaluate(tolerance float64) *Evaluate {
+// Default scale-up limits, ported from the classic HPA's scaleUpLimit damping: a single evaluation is never
+// allowed to grow replicas past whichever of these is larger.
+const (
+	defaultScaleUpLimitFactor  = 2
+	defaultScaleUpLimitMinimum = 4
+)
+
+// Option overrides part of the Evaluate NewEvaluate builds, letting downstream users of this package as a
+// library adjust individual defaults (which aggregation strategy to use, which sub-evaluator gets which default
+// tolerance) without having to construct every sub-evaluator by hand.
+type Option func(*Evaluate)
+
+// WithAggregator overrides the aggregation strategy NewEvaluate wires up, which otherwise defaults to
+// aggregate.Max, combining multiple metrics' evaluations the same way the classic HPA does.
+func WithAggregator(aggregator aggregate.Aggregator) Option {
+	return func(e *Evaluate) {
+		e.Aggregator = aggregator
+	}
+}
+
+// WithExternalTolerance overrides the default tolerance NewEvaluate gives its External evaluator, which
+// otherwise defaults to the tolerance argument passed to NewEvaluate.
+func WithExternalTolerance(tolerance float64) Option {
+	return func(e *Evaluate) {
+		if ext, ok := e.External.(*external.Evaluate); ok {
+			ext.Tolerance = tolerance
+		}
+	}
+}
+
+// WithObjectTolerance overrides the default tolerance NewEvaluate gives its Object evaluator, which otherwise
+// defaults to the tolerance argument passed to NewEvaluate.
+func WithObjectTolerance(tolerance float64) Option {
+	return func(e *Evaluate) {
+		if obj, ok := e.Object.(*object.Evaluate); ok {
+			obj.Tolerance = tolerance
+		}
+	}
+}
+
+// WithResourceTolerance overrides the default tolerance NewEvaluate gives its Resource evaluator, which
+// otherwise defaults to the tolerance argument passed to NewEvaluate. ContainerResource metrics have their own
+// evaluator, which this option does not change; it keeps the tolerance passed to NewEvaluate.
+func WithResourceTolerance(tolerance float64) Option {
+	return func(e *Evaluate) {
+		if res, ok := e.Resource.(*resource.Evaluate); ok {
+			res.Tolerance = tolerance
+		}
+	}
+}
+
+// NewEvaluate sets up an evaluate that can process external, object, pod and resource metrics, with a shared
+// replica calculater. opts are applied after every default is set, in order, so a later opt always wins.
+func NewEvaluate(tolerance float64, opts ...Option) *Evaluate {
 	calculate := &calculate.ReplicaCalculate{
 		Tolerance: tolerance,
 	}
-	return &Evaluate{
+	defaultLimiter := limit.Limiter{
+		ScaleUpLimitFactor:  defaultScaleUpLimitFactor,
+		ScaleUpLimitMinimum: defaultScaleUpLimitMinimum,
+	}
+	e := &Evaluate{
 		External: &external.Evaluate{
 			Calculater: calculate,
 			Tolerance:  tolerance,
+			Limiter:    defaultLimiter,
 		},
 		Object: &object.Evaluate{
 			Calculater: calculate,
 			Tolerance:  tolerance,
+			Limiter:    defaultLimiter,
 		},
 		Pods: &pods.Evaluate{
 			Calculater: calculate,
+			Limiter:    defaultLimiter,
 		},
 		Resource: &resource.Evaluate{
 			Calculater: calculate,
 			Tolerance:  tolerance,
+			Limiter:    defaultLimiter,
 		},
+		ContainerResource: &containerresource.Evaluate{
+			Calculater: calculate,
+			Tolerance:  tolerance,
+			Limiter:    defaultLimiter,
+		},
+		Prometheus: &prometheuseval.Evaluate{
+			Limiter: defaultLimiter,
+		},
+		MinReplicas:  1,
+		Recommenders: &behavior.MemoryRecommenderStore{},
+		Aggregator:   aggregate.Max{},
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
 }
 
-// GetEvaluation takes in metrics and outputs an evaluation decision
-func (e *Evaluate) GetEvaluation(gatheredMetrics []*metric.Metric) (*evaluate.Evaluation, error) {
-	var evaluation *evaluate.Evaluation
+// GetEvaluation takes in metrics and outputs an evaluation decision. resourceUID identifies the target resource
+// being evaluated, used to look up its recommendation history in Recommenders; behaviorRules is the target
+// resource's optional HorizontalPodAutoscalerBehavior, applied to the combined evaluation before MinReplicas is
+// enforced. Pass a nil behaviorRules to skip stabilization and scaling policies entirely.
+func (e *Evaluate) GetEvaluation(resourceUID types.UID, behaviorRules *autoscaling.HorizontalPodAutoscalerBehavior, gatheredMetrics []*metric.Metric) (*evaluate.Evaluation, error) {
+	var validMetrics []*metric.Metric
+	var proposals []*evaluate.Evaluation
 	var invalidEvaluationError error
 	invalidEvaluationsCount := 0
 
@@ -94,20 +199,38 @@ func (e *Evaluate) GetEvaluation(gatheredMetrics []*metric.Metric) (*evaluate.Ev
 			invalidEvaluationsCount++
 			continue
 		}
-		if evaluation == nil {
-			evaluation = proposedEvaluation
-			continue
-		}
-		// Mutliple evaluations, take the highest replica count
-		if proposedEvaluation.TargetReplicas > evaluation.TargetReplicas {
-			evaluation = proposedEvaluation
-		}
+		validMetrics = append(validMetrics, gatheredMetric)
+		proposals = append(proposals, proposedEvaluation)
 	}
 
 	// If all evaluations are invalid return error and return first evaluation error.
 	if invalidEvaluationsCount >= len(gatheredMetrics) {
 		return nil, fmt.Errorf("invalid evaluations (%v invalid out of %v), first error is: %v", invalidEvaluationsCount, len(gatheredMetrics), invalidEvaluationError)
 	}
+
+	aggregator := e.Aggregator
+	if _, ok := aggregator.(aggregate.Weighted); ok {
+		weights := make([]int32, len(validMetrics))
+		for i, validMetric := range validMetrics {
+			weights[i] = aggregate.MetricWeight(validMetric.Spec)
+		}
+		aggregator = aggregate.Weighted{Weights: weights}
+	}
+	evaluation := aggregator.Combine(proposals)
+
+	if behaviorRules != nil {
+		currentReplicas := gatheredMetrics[0].CurrentReplicas
+		limited, err := behavior.Apply(e.Recommenders.Get(resourceUID), behaviorRules, currentReplicas, evaluation.TargetReplicas)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply scaling behavior: %v", err)
+		}
+		evaluation.TargetReplicas = limited
+	}
+
+	if evaluation.TargetReplicas < e.MinReplicas {
+		evaluation.TargetReplicas = e.MinReplicas
+	}
+
 	return evaluation, nil
 }
 
@@ -121,6 +244,10 @@ func (e *Evaluate) getEvaluation(currentReplicas int32, gatheredMetric *metric.M
 		return e.Resource.GetEvaluation(currentReplicas, gatheredMetric)
 	case autoscaling.ExternalMetricSourceType:
 		return e.External.GetEvaluation(currentReplicas, gatheredMetric)
+	case autoscaling.ContainerResourceMetricSourceType:
+		return e.ContainerResource.GetEvaluation(currentReplicas, gatheredMetric)
+	case metricprometheus.MetricSourceType:
+		return e.Prometheus.GetEvaluation(currentReplicas, gatheredMetric)
 	default:
 		return nil, fmt.Errorf("unknown metric source type %q", string(gatheredMetric.Spec.Type))
 	}
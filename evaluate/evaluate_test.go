@@ -23,14 +23,20 @@ import (
 	"github.com/google/go-cmp/cmp"
 	cpaevaluate "github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/aggregate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/behavior"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/containerresource"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/external"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/object"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/pods"
+	prometheuseval "github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/prometheus"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/resource"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/fake"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
-	"k8s.io/api/autoscaling/v2beta2"
+	metricprometheus "github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+	"k8s.io/api/autoscaling/v2"
 )
 
 func TestNewEvaluate(t *testing.T) {
@@ -47,24 +53,59 @@ func TestNewEvaluate(t *testing.T) {
 					Calculater: &calculate.ReplicaCalculate{
 						Tolerance: 5,
 					},
+					Limiter: limit.Limiter{
+						ScaleUpLimitFactor:  2,
+						ScaleUpLimitMinimum: 4,
+					},
 				},
 				Object: &object.Evaluate{
 					Tolerance: 5,
 					Calculater: &calculate.ReplicaCalculate{
 						Tolerance: 5,
 					},
+					Limiter: limit.Limiter{
+						ScaleUpLimitFactor:  2,
+						ScaleUpLimitMinimum: 4,
+					},
 				},
 				Pods: &pods.Evaluate{
 					Calculater: &calculate.ReplicaCalculate{
 						Tolerance: 5,
 					},
+					Limiter: limit.Limiter{
+						ScaleUpLimitFactor:  2,
+						ScaleUpLimitMinimum: 4,
+					},
 				},
 				Resource: &resource.Evaluate{
 					Tolerance: 5,
 					Calculater: &calculate.ReplicaCalculate{
 						Tolerance: 5,
 					},
+					Limiter: limit.Limiter{
+						ScaleUpLimitFactor:  2,
+						ScaleUpLimitMinimum: 4,
+					},
 				},
+				ContainerResource: &containerresource.Evaluate{
+					Tolerance: 5,
+					Calculater: &calculate.ReplicaCalculate{
+						Tolerance: 5,
+					},
+					Limiter: limit.Limiter{
+						ScaleUpLimitFactor:  2,
+						ScaleUpLimitMinimum: 4,
+					},
+				},
+				Prometheus: &prometheuseval.Evaluate{
+					Limiter: limit.Limiter{
+						ScaleUpLimitFactor:  2,
+						ScaleUpLimitMinimum: 4,
+					},
+				},
+				MinReplicas:  1,
+				Recommenders: &behavior.MemoryRecommenderStore{},
+				Aggregator:   aggregate.Max{},
 			},
 			5,
 		},
@@ -88,331 +129,443 @@ func TestGetEvaluation(t *testing.T) {
 	})
 
 	var tests = []struct {
-		description     string
-		expected        *cpaevaluate.Evaluation
-		expectedErr     error
-		resource        resource.Evaluator
-		object          object.Evaluator
-		pods            pods.Evaluator
-		external        external.Evaluator
-		gatheredMetrics []*metric.Metric
+		description       string
+		expected          *cpaevaluate.Evaluation
+		expectedErr       error
+		resource          resource.Evaluator
+		object            object.Evaluator
+		pods              pods.Evaluator
+		external          external.Evaluator
+		gatheredMetrics   []*metric.Metric
+		containerResource containerresource.Evaluator
+		prometheus        prometheuseval.Evaluator
+		aggregator        aggregate.Aggregator
+		behaviorRules     *v2.HorizontalPodAutoscalerBehavior
 	}{
 		{
-			"Single unknown metric type",
-			nil,
-			errors.New(`invalid evaluations (1 invalid out of 1), first error is: unknown metric source type "invalid"`),
-			nil,
-			nil,
-			nil,
-			nil,
-			[]*metric.Metric{
+			description: "Single unknown metric type",
+			expectedErr: errors.New(`invalid evaluations (1 invalid out of 1), first error is: unknown metric source type "invalid"`),
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
+					Spec: v2.MetricSpec{
 						Type: "invalid",
 					},
 				},
 			},
 		},
 		{
-			"Single object metric, fail to evaluate",
-			nil,
-			errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
-			nil,
-			&fake.ObjectEvaluater{
+			description: "Single object metric, fail to evaluate",
+			expectedErr: errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
+			object: &fake.ObjectEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			nil,
-			nil,
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ObjectMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ObjectMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"Single object metric, success 3 replicas",
-			&cpaevaluate.Evaluation{
+			description: "Single object metric, success 3 replicas",
+			expected: &cpaevaluate.Evaluation{
 				TargetReplicas: 3,
 			},
-			nil,
-			nil,
-			&fake.ObjectEvaluater{
+			object: &fake.ObjectEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 3,
 					}, nil
 				},
 			},
-			nil,
-			nil,
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ObjectMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ObjectMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"Single pods metric, success 7 replicas",
-			&cpaevaluate.Evaluation{
+			description: "Single pods metric, success 7 replicas",
+			expected: &cpaevaluate.Evaluation{
 				TargetReplicas: 7,
 			},
-			nil,
-			nil,
-			nil,
-			&fake.PodsEvaluater{
+			pods: &fake.PodsEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) *cpaevaluate.Evaluation {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 7,
 					}
 				},
 			},
-			nil,
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.PodsMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.PodsMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"Single resource metric, fail to evaluate",
-			nil,
-			errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
-			&fake.ResourceEvaluater{
+			description: "Single resource metric, fail to evaluate",
+			expectedErr: errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
+			resource: &fake.ResourceEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			nil,
-			nil,
-			nil,
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ResourceMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ResourceMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"Single resource metric, success 9 replicas",
-			&cpaevaluate.Evaluation{
+			description: "Single resource metric, success 9 replicas",
+			expected: &cpaevaluate.Evaluation{
 				TargetReplicas: 9,
 			},
-			nil,
-			&fake.ResourceEvaluater{
+			resource: &fake.ResourceEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 9,
 					}, nil
 				},
 			},
-			nil,
-			nil,
-			nil,
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ResourceMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ResourceMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"Single external metric, fail to evaluate",
-			nil,
-			errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
-			nil,
-			nil,
-			nil,
-			&fake.ExternalEvaluater{
+			description: "Single external metric, fail to evaluate",
+			expectedErr: errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
+			external: &fake.ExternalEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ExternalMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ExternalMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"Single external metric, success 2 replicas",
-			&cpaevaluate.Evaluation{
+			description: "Single external metric, success 2 replicas",
+			expected: &cpaevaluate.Evaluation{
 				TargetReplicas: 2,
 			},
-			nil,
-			nil,
-			nil,
-			nil,
-			&fake.ExternalEvaluater{
+			external: &fake.ExternalEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 2,
 					}, nil
 				},
 			},
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
+				{
+					Spec: v2.MetricSpec{
+						Type: v2.ExternalMetricSourceType,
+					},
+				},
+			},
+		},
+		{
+			description: "Single container resource metric, fail to evaluate",
+			expectedErr: errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
+			containerResource: &fake.ContainerResourceEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
+					return nil, errors.New("fail to evaluate")
+				},
+			},
+			gatheredMetrics: []*metric.Metric{
+				{
+					Spec: v2.MetricSpec{
+						Type: v2.ContainerResourceMetricSourceType,
+					},
+				},
+			},
+		},
+		{
+			description: "Single container resource metric, success 6 replicas",
+			expected: &cpaevaluate.Evaluation{
+				TargetReplicas: 6,
+			},
+			containerResource: &fake.ContainerResourceEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
+					return &cpaevaluate.Evaluation{
+						TargetReplicas: 6,
+					}, nil
+				},
+			},
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ExternalMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ContainerResourceMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"One of resource, object and external metric all invalid",
-			nil,
-			errors.New("invalid evaluations (3 invalid out of 3), first error is: fail to evaluate"),
-			&fake.ResourceEvaluater{
+			description: "Single Prometheus metric, fail to evaluate",
+			expectedErr: errors.New("invalid evaluations (1 invalid out of 1), first error is: fail to evaluate"),
+			prometheus: &fake.PrometheusEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			&fake.ObjectEvaluater{
+			gatheredMetrics: []*metric.Metric{
+				{
+					Spec: v2.MetricSpec{
+						Type: metricprometheus.MetricSourceType,
+					},
+				},
+			},
+		},
+		{
+			description: "Single Prometheus metric, success 4 replicas",
+			expected: &cpaevaluate.Evaluation{
+				TargetReplicas: 4,
+			},
+			prometheus: &fake.PrometheusEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
+					return &cpaevaluate.Evaluation{
+						TargetReplicas: 4,
+					}, nil
+				},
+			},
+			gatheredMetrics: []*metric.Metric{
+				{
+					Spec: v2.MetricSpec{
+						Type: metricprometheus.MetricSourceType,
+					},
+				},
+			},
+		},
+		{
+			description: "One of resource, object and external metric all invalid",
+			expectedErr: errors.New("invalid evaluations (3 invalid out of 3), first error is: fail to evaluate"),
+			resource: &fake.ResourceEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			nil,
-			&fake.ExternalEvaluater{
+			object: &fake.ObjectEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			[]*metric.Metric{
+			external: &fake.ExternalEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
+					return nil, errors.New("fail to evaluate")
+				},
+			},
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ObjectMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ObjectMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ResourceMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ResourceMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ExternalMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ExternalMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"One of each metric, 2 success, 2 invalid, take the highest",
-			&cpaevaluate.Evaluation{
+			description: "One of each metric, 2 success, 2 invalid, take the highest",
+			expected: &cpaevaluate.Evaluation{
 				TargetReplicas: 5,
 			},
-			nil,
-			&fake.ResourceEvaluater{
+			resource: &fake.ResourceEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			&fake.ObjectEvaluater{
+			object: &fake.ObjectEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 5,
 					}, nil
 				},
 			},
-			&fake.PodsEvaluater{
+			pods: &fake.PodsEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) *cpaevaluate.Evaluation {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 1,
 					}
 				},
 			},
-			&fake.ExternalEvaluater{
+			external: &fake.ExternalEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return nil, errors.New("fail to evaluate")
 				},
 			},
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ObjectMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ObjectMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ResourceMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ResourceMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.PodsMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.PodsMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ExternalMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ExternalMetricSourceType,
 					},
 				},
 			},
 		},
 		{
-			"Once of each metric, all success, take the highest",
-			&cpaevaluate.Evaluation{
+			description: "Once of each metric, all success, take the highest",
+			expected: &cpaevaluate.Evaluation{
 				TargetReplicas: 9,
 			},
-			nil,
-			&fake.ResourceEvaluater{
+			resource: &fake.ResourceEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 5,
 					}, nil
 				},
 			},
-			&fake.ObjectEvaluater{
+			object: &fake.ObjectEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: -25,
 					}, nil
 				},
 			},
-			&fake.PodsEvaluater{
+			pods: &fake.PodsEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) *cpaevaluate.Evaluation {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 3,
 					}
 				},
 			},
-			&fake.ExternalEvaluater{
+			external: &fake.ExternalEvaluater{
 				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
 					return &cpaevaluate.Evaluation{
 						TargetReplicas: 9,
 					}, nil
 				},
 			},
-			[]*metric.Metric{
+			gatheredMetrics: []*metric.Metric{
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ObjectMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ObjectMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ResourceMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ResourceMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.PodsMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.PodsMetricSourceType,
 					},
 				},
 				{
-					Spec: v2beta2.MetricSpec{
-						Type: v2beta2.ExternalMetricSourceType,
+					Spec: v2.MetricSpec{
+						Type: v2.ExternalMetricSourceType,
+					},
+				},
+			},
+		},
+		{
+			description: "Once of each metric, all success, Min aggregator takes the lowest instead of the highest",
+			expected: &cpaevaluate.Evaluation{
+				TargetReplicas: 3,
+			},
+			aggregator: aggregate.Min{},
+			resource: &fake.ResourceEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
+					return &cpaevaluate.Evaluation{
+						TargetReplicas: 5,
+					}, nil
+				},
+			},
+			pods: &fake.PodsEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) *cpaevaluate.Evaluation {
+					return &cpaevaluate.Evaluation{
+						TargetReplicas: 3,
+					}
+				},
+			},
+			external: &fake.ExternalEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) (*cpaevaluate.Evaluation, error) {
+					return &cpaevaluate.Evaluation{
+						TargetReplicas: 9,
+					}, nil
+				},
+			},
+			gatheredMetrics: []*metric.Metric{
+				{
+					Spec: v2.MetricSpec{
+						Type: v2.ResourceMetricSourceType,
+					},
+				},
+				{
+					Spec: v2.MetricSpec{
+						Type: v2.PodsMetricSourceType,
+					},
+				},
+				{
+					Spec: v2.MetricSpec{
+						Type: v2.ExternalMetricSourceType,
+					},
+				},
+			},
+		},
+		{
+			description: "behaviorRules caps the combined evaluation using a scale-up Pods policy",
+			expected: &cpaevaluate.Evaluation{
+				TargetReplicas: 14,
+			},
+			behaviorRules: &v2.HorizontalPodAutoscalerBehavior{
+				ScaleUp: &v2.HPAScalingRules{
+					Policies: []v2.HPAScalingPolicy{
+						{Type: v2.PodsScalingPolicy, Value: 4, PeriodSeconds: 60},
+					},
+				},
+			},
+			pods: &fake.PodsEvaluater{
+				GetEvaluationReactor: func(currentReplicas int32, gatheredMetric *metric.Metric) *cpaevaluate.Evaluation {
+					return &cpaevaluate.Evaluation{
+						TargetReplicas: 100,
+					}
+				},
+			},
+			gatheredMetrics: []*metric.Metric{
+				{
+					CurrentReplicas: 10,
+					Spec: v2.MetricSpec{
+						Type: v2.PodsMetricSourceType,
 					},
 				},
 			},
@@ -420,13 +573,21 @@ func TestGetEvaluation(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
+			aggregator := test.aggregator
+			if aggregator == nil {
+				aggregator = aggregate.Max{}
+			}
 			evaluater := evaluate.Evaluate{
-				External: test.external,
-				Object:   test.object,
-				Pods:     test.pods,
-				Resource: test.resource,
+				External:          test.external,
+				Object:            test.object,
+				Pods:              test.pods,
+				Resource:          test.resource,
+				ContainerResource: test.containerResource,
+				Prometheus:        test.prometheus,
+				Aggregator:        aggregator,
+				Recommenders:      &behavior.MemoryRecommenderStore{},
 			}
-			evaluation, err := evaluater.GetEvaluation(test.gatheredMetrics)
+			evaluation, err := evaluater.GetEvaluation("", test.behaviorRules, test.gatheredMetrics)
 			if !cmp.Equal(&err, &test.expectedErr, equateErrorMessage) {
 				t.Errorf("error mismatch (-want +got):\n%s", cmp.Diff(test.expectedErr, err, equateErrorMessage))
 				return
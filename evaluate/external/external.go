@@ -30,12 +30,20 @@ package external
 import (
 	"fmt"
 	"math"
+	"strconv"
 
 	"github.com/jthomperoo/custom-pod-autoscaler/evaluate"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// toleranceLabel is the MatchLabels key a MetricSpec's Metric.Selector can carry to override Tolerance for that
+// individual metric, following the same convention evaluate/aggregate.WeightLabel uses to carry a metric's
+// aggregation weight.
+const toleranceLabel = "tolerance"
+
 // Evaluator (external) produces an evaluation based on a resource metric provided
 type Evaluator interface {
 	GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
@@ -45,21 +53,28 @@ type Evaluator interface {
 type Evaluate struct {
 	Calculater calculate.Calculater
 	Tolerance  float64
+	limit.Limiter
 }
 
 // GetEvaluation calculates an evaluation based on the metric provided and the current number of replicas
 func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	tolerance := e.tolerance(gatheredMetric.Spec.External.Metric.Selector)
+	calculater := e.Calculater
+	if tolerance != e.Tolerance {
+		calculater = &calculate.ReplicaCalculate{Tolerance: tolerance}
+	}
+
 	if gatheredMetric.Spec.External.Target.AverageValue != nil {
 		utilization := gatheredMetric.External.Utilization
 		targetUtilizationPerPod := gatheredMetric.Spec.External.Target.AverageValue.MilliValue()
 		replicaCount := currentReplicas
 		usageRatio := float64(utilization) / (float64(targetUtilizationPerPod) * float64(replicaCount))
-		if math.Abs(1.0-usageRatio) > e.Tolerance {
+		if math.Abs(1.0-usageRatio) > tolerance {
 			// update number of replicas if the change is large enough
 			replicaCount = int32(math.Ceil(float64(utilization) / float64(targetUtilizationPerPod)))
 		}
 		return &evaluate.Evaluation{
-			TargetReplicas: replicaCount,
+			TargetReplicas: e.Limit(currentReplicas, replicaCount),
 		}, nil
 	}
 
@@ -72,10 +87,28 @@ func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.M
 		readyPodCount := gatheredMetric.External.ReadyPodCount
 
 		usageRatio := float64(utilization) / float64(targetUtilization)
-		replicaCount = e.Calculater.GetUsageRatioReplicaCount(currentReplicas, usageRatio, *readyPodCount)
+		replicaCount = calculater.GetUsageRatioReplicaCount(currentReplicas, usageRatio, *readyPodCount)
 		return &evaluate.Evaluation{
-			TargetReplicas: replicaCount,
+			TargetReplicas: e.Limit(currentReplicas, replicaCount),
 		}, nil
 	}
 	return nil, fmt.Errorf("invalid external metric source: neither a value target nor an average value target was set")
 }
+
+// tolerance resolves the tolerance to apply for a metric carrying selector, preferring the per-metric override
+// carried under toleranceLabel and falling back to e.Tolerance if selector is nil, carries no such label, or the
+// label's value isn't a valid non-negative tolerance.
+func (e *Evaluate) tolerance(selector *metav1.LabelSelector) float64 {
+	if selector == nil {
+		return e.Tolerance
+	}
+	raw, ok := selector.MatchLabels[toleranceLabel]
+	if !ok {
+		return e.Tolerance
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		return e.Tolerance
+	}
+	return value
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpc implements evaluate/external.Evaluator by delegating to a KEDA-compatible external scaler over
+// gRPC (the externalscaler.proto contract: IsActive, GetMetricSpec, GetMetrics), rather than computing replicas
+// from a gathered value directly. This lets any of the existing KEDA scalers (Kafka lag, RabbitMQ queue depth,
+// Prometheus queries, cloud queues, ...) drive scaling without a bespoke gatherer.
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jthomperoo/custom-pod-autoscaler/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	pb "github.com/kedacore/keda/v2/pkg/scalers/externalscaler"
+)
+
+// Evaluate (grpc) evaluates an External metric source by asking a connected KEDA external scaler whether it is
+// active, and if so, by requesting its current metric value and dividing it by the configured target. An
+// inactive scaler evaluates to zero replicas, enabling scale-to-zero; the caller's evaluate.Evaluate must be
+// configured with MinReplicas: 0 for a zero recommendation to survive being combined with other metrics.
+type Evaluate struct {
+	// Client is a connected externalscaler.ExternalScalerClient, dialed once at startup and reused across calls.
+	Client pb.ExternalScalerClient
+	// Name identifies the scaled resource to the external scaler, set as the ScaledObjectRef name.
+	Name string
+	// Namespace identifies the scaled resource's namespace to the external scaler.
+	Namespace string
+	// ScalerName is passed to the external scaler as the "scalerName" metadata key, selecting which underlying
+	// KEDA scaler implementation (Kafka, RabbitMQ, Prometheus, ...) should handle the request.
+	ScalerName string
+}
+
+// GetEvaluation asks the configured external scaler whether it is active; if not, it returns a TargetReplicas of
+// 0. If active, it requests the scaler's current metric value and divides it by the metric's configured target
+// (Value or AverageValue) to produce a replica count.
+func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	ref := e.scaledObjectRef(gatheredMetric)
+
+	active, err := e.Client.IsActive(context.Background(), ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if external scaler %q is active: %v", e.ScalerName, err)
+	}
+	if !active.Result {
+		return &evaluate.Evaluation{TargetReplicas: 0}, nil
+	}
+
+	target := gatheredMetric.Spec.External.Target
+	var targetValue int64
+	switch {
+	case target.AverageValue != nil:
+		targetValue = target.AverageValue.MilliValue()
+	case target.Value != nil:
+		targetValue = target.Value.MilliValue()
+	default:
+		return nil, fmt.Errorf("invalid external metric source: neither a value target nor an average value target was set")
+	}
+	if targetValue == 0 {
+		return nil, fmt.Errorf("invalid external metric source: target value must be non-zero")
+	}
+
+	metricValues, err := e.Client.GetMetrics(context.Background(), &pb.GetMetricsRequest{
+		MetricName:      gatheredMetric.Spec.External.Metric.Name,
+		ScaledObjectRef: ref,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics from external scaler %q: %v", e.ScalerName, err)
+	}
+
+	var total int64
+	for _, value := range metricValues.MetricValues {
+		total += value.MetricValue * 1000
+	}
+
+	replicaCount := int32(math.Ceil(float64(total) / float64(targetValue)))
+	if replicaCount < 0 {
+		replicaCount = 0
+	}
+
+	return &evaluate.Evaluation{TargetReplicas: replicaCount}, nil
+}
+
+func (e *Evaluate) scaledObjectRef(gatheredMetric *metric.Metric) *pb.ScaledObjectRef {
+	metadata := map[string]string{"scalerName": e.ScalerName}
+	if gatheredMetric.Spec.External.Metric.Selector != nil {
+		for key, value := range gatheredMetric.Spec.External.Metric.Selector.MatchLabels {
+			metadata[key] = value
+		}
+	}
+	return &pb.ScaledObjectRef{
+		Name:           e.Name,
+		Namespace:      e.Namespace,
+		ScalerMetadata: metadata,
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jthomperoo/custom-pod-autoscaler/evaluate"
+	extgrpc "github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/external/grpc"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	pb "github.com/kedacore/keda/v2/pkg/scalers/externalscaler"
+	"google.golang.org/grpc"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type fakeClient struct {
+	isActive   func(*pb.ScaledObjectRef) (*pb.IsActiveResponse, error)
+	getMetrics func(*pb.GetMetricsRequest) (*pb.GetMetricsResponse, error)
+}
+
+func (f *fakeClient) IsActive(ctx context.Context, in *pb.ScaledObjectRef, opts ...grpc.CallOption) (*pb.IsActiveResponse, error) {
+	return f.isActive(in)
+}
+
+func (f *fakeClient) StreamIsActive(ctx context.Context, in *pb.ScaledObjectRef, opts ...grpc.CallOption) (pb.ExternalScaler_StreamIsActiveClient, error) {
+	return nil, errors.New("not implemented by fakeClient")
+}
+
+func (f *fakeClient) GetMetricSpec(ctx context.Context, in *pb.ScaledObjectRef, opts ...grpc.CallOption) (*pb.GetMetricSpecResponse, error) {
+	return nil, errors.New("not implemented by fakeClient")
+}
+
+func (f *fakeClient) GetMetrics(ctx context.Context, in *pb.GetMetricsRequest, opts ...grpc.CallOption) (*pb.GetMetricsResponse, error) {
+	return f.getMetrics(in)
+}
+
+func externalMetric(targetValue string) *metric.Metric {
+	quantity := resource.MustParse(targetValue)
+	return &metric.Metric{
+		Spec: autoscaling.MetricSpec{
+			External: &autoscaling.ExternalMetricSource{
+				Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+				Target: autoscaling.MetricTarget{
+					Type:         autoscaling.AverageValueMetricType,
+					AverageValue: &quantity,
+				},
+			},
+		},
+	}
+}
+
+func TestGetEvaluation(t *testing.T) {
+	t.Run("inactive scaler evaluates to zero replicas", func(t *testing.T) {
+		client := &fakeClient{
+			isActive: func(*pb.ScaledObjectRef) (*pb.IsActiveResponse, error) {
+				return &pb.IsActiveResponse{Result: false}, nil
+			},
+		}
+		evaluator := &extgrpc.Evaluate{Client: client, Name: "my-deployment", Namespace: "default"}
+
+		evaluation, err := evaluator.GetEvaluation(3, externalMetric("10"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 0}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("active scaler divides the metric value by the target", func(t *testing.T) {
+		client := &fakeClient{
+			isActive: func(*pb.ScaledObjectRef) (*pb.IsActiveResponse, error) {
+				return &pb.IsActiveResponse{Result: true}, nil
+			},
+			getMetrics: func(*pb.GetMetricsRequest) (*pb.GetMetricsResponse, error) {
+				return &pb.GetMetricsResponse{
+					MetricValues: []*pb.MetricValue{
+						{MetricName: "queue_length", MetricValue: 25},
+					},
+				}, nil
+			},
+		}
+		evaluator := &extgrpc.Evaluate{Client: client, Name: "my-deployment", Namespace: "default"}
+
+		evaluation, err := evaluator.GetEvaluation(3, externalMetric("10"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &evaluate.Evaluation{TargetReplicas: 3}
+		if !cmp.Equal(expected, evaluation) {
+			t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(expected, evaluation))
+		}
+	})
+
+	t.Run("IsActive error is propagated", func(t *testing.T) {
+		client := &fakeClient{
+			isActive: func(*pb.ScaledObjectRef) (*pb.IsActiveResponse, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		evaluator := &extgrpc.Evaluate{Client: client}
+
+		_, err := evaluator.GetEvaluation(3, externalMetric("10"))
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package limit provides the scaleUpLimit/scaleDownLimit damping shared by every metric-source evaluator
+// (resource, external, object, pods, containerresource, prometheus), capping how aggressively a single
+// evaluation can change the replica count relative to currentReplicas.
+package limit
+
+import "math"
+
+// Limiter caps how aggressively a proposed replica count can grow or shrink relative to currentReplicas in a
+// single evaluation, mirroring the classic HPA scaleUpLimit/scaleDownLimit damping. Each metric-source Evaluate
+// embeds a Limiter rather than redefining these fields itself.
+type Limiter struct {
+	// ScaleUpLimitFactor caps growth to at most ScaleUpLimitFactor times currentReplicas in a single evaluation.
+	// A zero value disables the limit.
+	ScaleUpLimitFactor float64
+	// ScaleUpLimitMinimum is the minimum replica count scaling up is always allowed to reach, even if
+	// ScaleUpLimitFactor would cap it lower. A zero value disables the floor.
+	ScaleUpLimitMinimum int32
+	// ScaleDownLimitFactor caps shrinkage to at most currentReplicas/ScaleDownLimitFactor in a single evaluation.
+	// A zero value disables the limit.
+	ScaleDownLimitFactor float64
+	// ScaleDownLimitMinimum is the number of replicas scaling down is always allowed to remove in a single
+	// evaluation, even if ScaleDownLimitFactor would allow fewer to be removed. A zero value disables it.
+	ScaleDownLimitMinimum int32
+}
+
+// Limit caps how aggressively targetReplicas can grow or shrink relative to currentReplicas in a single
+// evaluation.
+func (l *Limiter) Limit(currentReplicas, targetReplicas int32) int32 {
+	if l.ScaleUpLimitFactor > 0 && targetReplicas > currentReplicas {
+		scaleUpLimit := int32(math.Max(l.ScaleUpLimitFactor*float64(currentReplicas), float64(l.ScaleUpLimitMinimum)))
+		if targetReplicas > scaleUpLimit {
+			return scaleUpLimit
+		}
+	}
+	if l.ScaleDownLimitFactor > 0 && targetReplicas < currentReplicas {
+		scaleDownLimit := int32(math.Max(math.Min(float64(currentReplicas)/l.ScaleDownLimitFactor, float64(currentReplicas-l.ScaleDownLimitMinimum)), 0))
+		if targetReplicas < scaleDownLimit {
+			return scaleDownLimit
+		}
+	}
+	return targetReplicas
+}
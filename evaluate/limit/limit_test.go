@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limit_test
+
+import (
+	"testing"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
+)
+
+func TestLimiter_Limit(t *testing.T) {
+	var tests = []struct {
+		description           string
+		targetReplicas        int32
+		currentReplicas       int32
+		scaleUpLimitFactor    float64
+		scaleUpLimitMinimum   int32
+		scaleDownLimitFactor  float64
+		scaleDownLimitMinimum int32
+		expected              int32
+	}{
+		{"Uncapped growth, within the factor limit", 15, 10, 2, 4, 0, 0, 15},
+		{"Capped growth, hits the factor limit", 30, 10, 2, 4, 0, 0, 20},
+		{"Capped growth, hits the minimum floor with few current replicas", 10, 1, 2, 4, 0, 0, 4},
+		{"No-op, target already within the cap", 10, 10, 2, 4, 0, 0, 10},
+		{"Capped shrinkage, hits the scale down factor limit", 1, 10, 0, 0, 2, 4, 5},
+		{"Unconfigured limits leave target unchanged", 100, 10, 0, 0, 0, 0, 100},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			l := &limit.Limiter{
+				ScaleUpLimitFactor:    test.scaleUpLimitFactor,
+				ScaleUpLimitMinimum:   test.scaleUpLimitMinimum,
+				ScaleDownLimitFactor:  test.scaleDownLimitFactor,
+				ScaleDownLimitMinimum: test.scaleDownLimitMinimum,
+			}
+			result := l.Limit(test.currentReplicas, test.targetReplicas)
+			if result != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, result)
+			}
+		})
+	}
+}
@@ -30,13 +30,21 @@ package object
 import (
 	"fmt"
 	"math"
+	"strconv"
 
 	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
-	autoscaling "k8s.io/api/autoscaling/v2beta2"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// toleranceLabel is the MatchLabels key a MetricSpec's Metric.Selector can carry to override Tolerance for that
+// individual metric, following the same convention evaluate/aggregate.WeightLabel uses to carry a metric's
+// aggregation weight.
+const toleranceLabel = "tolerance"
+
 // Evaluator (object) produces an evaluation based on an object metric provided
 type Evaluator interface {
 	GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
@@ -46,28 +54,53 @@ type Evaluator interface {
 type Evaluate struct {
 	Calculater calculate.Calculater
 	Tolerance  float64
+	limit.Limiter
 }
 
 // GetEvaluation calculates an evaluation based on the metric provided and the current number of replicas
 func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	tolerance := e.tolerance(gatheredMetric.Spec.Object.Metric.Selector)
+	calculater := e.Calculater
+	if tolerance != e.Tolerance {
+		calculater = &calculate.ReplicaCalculate{Tolerance: tolerance}
+	}
+
 	if gatheredMetric.Spec.Object.Target.Type == autoscaling.ValueMetricType {
 		usageRatio := float64(gatheredMetric.Object.Utilization) / float64(gatheredMetric.Spec.Object.Target.Value.MilliValue())
-		replicaCount := e.Calculater.GetUsageRatioReplicaCount(currentReplicas, usageRatio, *gatheredMetric.Object.ReadyPodCount)
+		replicaCount := calculater.GetUsageRatioReplicaCount(currentReplicas, usageRatio, *gatheredMetric.Object.ReadyPodCount)
 		return &evaluate.Evaluation{
-			TargetReplicas: replicaCount,
+			TargetReplicas: e.Limit(currentReplicas, replicaCount),
 		}, nil
 	}
 	if gatheredMetric.Spec.Object.Target.Type == autoscaling.AverageValueMetricType {
 		utilization := gatheredMetric.Object.Utilization
 		replicaCount := currentReplicas
 		usageRatio := float64(utilization) / (float64(gatheredMetric.Spec.Object.Target.AverageValue.MilliValue()) * float64(replicaCount))
-		if math.Abs(1.0-usageRatio) > e.Tolerance {
+		if math.Abs(1.0-usageRatio) > tolerance {
 			// update number of replicas if change is large enough
 			replicaCount = int32(math.Ceil(float64(utilization) / float64(gatheredMetric.Spec.Object.Target.AverageValue.MilliValue())))
 		}
 		return &evaluate.Evaluation{
-			TargetReplicas: replicaCount,
+			TargetReplicas: e.Limit(currentReplicas, replicaCount),
 		}, nil
 	}
 	return nil, fmt.Errorf("invalid object metric source: neither a value target nor an average value target was set")
 }
+
+// tolerance resolves the tolerance to apply for a metric carrying selector, preferring the per-metric override
+// carried under toleranceLabel and falling back to e.Tolerance if selector is nil, carries no such label, or the
+// label's value isn't a valid non-negative tolerance.
+func (e *Evaluate) tolerance(selector *metav1.LabelSelector) float64 {
+	if selector == nil {
+		return e.Tolerance
+	}
+	raw, ok := selector.MatchLabels[toleranceLabel]
+	if !ok {
+		return e.Tolerance
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		return e.Tolerance
+	}
+	return value
+}
@@ -27,8 +27,9 @@ import (
 	"github.com/jthomperoo/horizontal-pod-autoscaler/fake"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
 	objectmetric "github.com/jthomperoo/horizontal-pod-autoscaler/metric/object"
-	"k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func int32Ptr(i int32) *int32 {
@@ -64,8 +65,8 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			3,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Object: &v2beta2.ObjectMetricSource{},
+				Spec: v2.MetricSpec{
+					Object: &v2.ObjectMetricSource{},
 				},
 			},
 		},
@@ -79,10 +80,10 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			5,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Object: &v2beta2.ObjectMetricSource{
-						Target: v2beta2.MetricTarget{
-							Type:         v2beta2.AverageValueMetricType,
+				Spec: v2.MetricSpec{
+					Object: &v2.ObjectMetricSource{
+						Target: v2.MetricTarget{
+							Type:         v2.AverageValueMetricType,
 							AverageValue: resource.NewMilliQuantity(50, resource.DecimalSI),
 						},
 					},
@@ -102,10 +103,10 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			5,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Object: &v2beta2.ObjectMetricSource{
-						Target: v2beta2.MetricTarget{
-							Type:         v2beta2.AverageValueMetricType,
+				Spec: v2.MetricSpec{
+					Object: &v2.ObjectMetricSource{
+						Target: v2.MetricTarget{
+							Type:         v2.AverageValueMetricType,
 							AverageValue: resource.NewMilliQuantity(50, resource.DecimalSI),
 						},
 					},
@@ -115,6 +116,58 @@ func TestGetEvaluation(t *testing.T) {
 				},
 			},
 		},
+		{
+			"Success, average value, per-metric tolerance override triggers a scale the default tolerance wouldn't",
+			&evaluate.Evaluation{
+				TargetReplicas: 10,
+			},
+			nil,
+			nil,
+			1.5,
+			5,
+			&metric.Metric{
+				Spec: v2.MetricSpec{
+					Object: &v2.ObjectMetricSource{
+						Metric: v2.MetricIdentifier{
+							Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tolerance": "0.5"}},
+						},
+						Target: v2.MetricTarget{
+							Type:         v2.AverageValueMetricType,
+							AverageValue: resource.NewMilliQuantity(50, resource.DecimalSI),
+						},
+					},
+				},
+				Object: &objectmetric.Metric{
+					Utilization: 500,
+				},
+			},
+		},
+		{
+			"Success, average value, invalid tolerance override falls back to the default tolerance",
+			&evaluate.Evaluation{
+				TargetReplicas: 5,
+			},
+			nil,
+			nil,
+			1.5,
+			5,
+			&metric.Metric{
+				Spec: v2.MetricSpec{
+					Object: &v2.ObjectMetricSource{
+						Metric: v2.MetricIdentifier{
+							Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tolerance": "not-a-number"}},
+						},
+						Target: v2.MetricTarget{
+							Type:         v2.AverageValueMetricType,
+							AverageValue: resource.NewMilliQuantity(50, resource.DecimalSI),
+						},
+					},
+				},
+				Object: &objectmetric.Metric{
+					Utilization: 500,
+				},
+			},
+		},
 		{
 			"Success, value",
 			&evaluate.Evaluation{
@@ -129,10 +182,10 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			5,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Object: &v2beta2.ObjectMetricSource{
-						Target: v2beta2.MetricTarget{
-							Type:  v2beta2.ValueMetricType,
+				Spec: v2.MetricSpec{
+					Object: &v2.ObjectMetricSource{
+						Target: v2.MetricTarget{
+							Type:  v2.ValueMetricType,
 							Value: resource.NewMilliQuantity(50, resource.DecimalSI),
 						},
 					},
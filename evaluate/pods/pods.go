@@ -30,6 +30,7 @@ package pods
 import (
 	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
 )
 
@@ -41,18 +42,20 @@ type Evaluator interface {
 // Evaluate (pods) calculates a replica count evaluation, using the tolerance and calculater provided
 type Evaluate struct {
 	Calculater calculate.Calculater
+	limit.Limiter
 }
 
 // GetEvaluation calculates an evaluation based on the metric provided and the current number of replicas
 func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) *evaluate.Evaluation {
+	replicaCount := e.Calculater.GetPlainMetricReplicaCount(
+		gatheredMetric.Pods.PodMetricsInfo,
+		currentReplicas,
+		gatheredMetric.Spec.Pods.Target.AverageValue.MilliValue(),
+		gatheredMetric.Pods.ReadyPodCount,
+		gatheredMetric.Pods.MissingPods,
+		gatheredMetric.Pods.IgnoredPods,
+	)
 	return &evaluate.Evaluation{
-		TargetReplicas: e.Calculater.GetPlainMetricReplicaCount(
-			gatheredMetric.Pods.PodMetricsInfo,
-			currentReplicas,
-			gatheredMetric.Spec.Pods.Target.AverageValue.MilliValue(),
-			gatheredMetric.Pods.ReadyPodCount,
-			gatheredMetric.Pods.MissingPods,
-			gatheredMetric.Pods.IgnoredPods,
-		),
+		TargetReplicas: e.Limit(currentReplicas, replicaCount),
 	}
 }
@@ -26,7 +26,7 @@ import (
 	"github.com/jthomperoo/horizontal-pod-autoscaler/fake"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
 	metricpods "github.com/jthomperoo/horizontal-pod-autoscaler/metric/pods"
-	"k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
@@ -53,9 +53,9 @@ func TestGetEvaluation(t *testing.T) {
 			4,
 			&metric.Metric{
 				CurrentReplicas: 4,
-				Spec: v2beta2.MetricSpec{
-					Pods: &v2beta2.PodsMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Pods: &v2.PodsMetricSource{
+						Target: v2.MetricTarget{
 							Value: resource.NewMilliQuantity(50, resource.DecimalSI),
 						},
 					},
@@ -0,0 +1,77 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+Modifications Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+
+Modified to split up evaluations and metric gathering to work with the
+Custom Pod Autoscaler framework.
+Original source:
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/horizontal.go
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/replica_calculator.go
+*/
+
+package prometheus
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jthomperoo/custom-pod-autoscaler/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+)
+
+// Evaluator (Prometheus) produces an evaluation based on a metric computed by a PromQL query.
+type Evaluator interface {
+	GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
+}
+
+// Evaluate (Prometheus) calculates a replica count evaluation by dividing the PromQL query's result by the
+// metric's configured target, reusing the same Value/AverageValue target shape as an External metric source.
+type Evaluate struct {
+	limit.Limiter
+}
+
+// GetEvaluation calculates an evaluation based on the metric provided and the current number of replicas.
+func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	target := gatheredMetric.Spec.External.Target
+
+	var targetValue int64
+	switch {
+	case target.AverageValue != nil:
+		targetValue = target.AverageValue.MilliValue()
+	case target.Value != nil:
+		targetValue = target.Value.MilliValue()
+	default:
+		return nil, fmt.Errorf("invalid prometheus metric source: neither a value target nor an average value target was set")
+	}
+	if targetValue == 0 {
+		return nil, fmt.Errorf("invalid prometheus metric source: target value must be non-zero")
+	}
+
+	value := gatheredMetric.Prometheus.Value * 1000
+
+	replicaCount := int32(math.Ceil(float64(value) / float64(targetValue)))
+	if replicaCount < 0 {
+		replicaCount = 0
+	}
+
+	return &evaluate.Evaluation{
+		TargetReplicas: e.Limit(currentReplicas, replicaCount),
+	}, nil
+}
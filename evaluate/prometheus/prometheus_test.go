@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jthomperoo/custom-pod-autoscaler/evaluate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
+	evaluateprometheus "github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/prometheus"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	metricprometheus "github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetEvaluation(t *testing.T) {
+	equateErrorMessage := cmp.Comparer(func(x, y error) bool {
+		if x == nil || y == nil {
+			return x == nil && y == nil
+		}
+		return x.Error() == y.Error()
+	})
+
+	var tests = []struct {
+		description     string
+		expected        *evaluate.Evaluation
+		expectedErr     error
+		evaluater       *evaluateprometheus.Evaluate
+		currentReplicas int32
+		gatheredMetric  *metric.Metric
+	}{
+		{
+			"Invalid metric source, no target set",
+			nil,
+			errors.New("invalid prometheus metric source: neither a value target nor an average value target was set"),
+			&evaluateprometheus.Evaluate{},
+			3,
+			&metric.Metric{
+				Spec: autoscaling.MetricSpec{
+					External: &autoscaling.ExternalMetricSource{},
+				},
+			},
+		},
+		{
+			"Invalid metric source, zero target value",
+			nil,
+			errors.New("invalid prometheus metric source: target value must be non-zero"),
+			&evaluateprometheus.Evaluate{},
+			3,
+			&metric.Metric{
+				Spec: autoscaling.MetricSpec{
+					External: &autoscaling.ExternalMetricSource{
+						Target: autoscaling.MetricTarget{
+							Value: k8sresource.NewQuantity(0, k8sresource.DecimalSI),
+						},
+					},
+				},
+			},
+		},
+		{
+			"Success, value target",
+			&evaluate.Evaluation{
+				TargetReplicas: 4,
+			},
+			nil,
+			&evaluateprometheus.Evaluate{},
+			2,
+			&metric.Metric{
+				Spec: autoscaling.MetricSpec{
+					External: &autoscaling.ExternalMetricSource{
+						Target: autoscaling.MetricTarget{
+							Value: k8sresource.NewQuantity(10, k8sresource.DecimalSI),
+						},
+					},
+				},
+				Prometheus: &metricprometheus.Metric{
+					Value:     40,
+					Timestamp: time.Unix(0, 0),
+				},
+			},
+		},
+		{
+			"Success, average value target, scale down limited",
+			&evaluate.Evaluation{
+				TargetReplicas: 2,
+			},
+			nil,
+			&evaluateprometheus.Evaluate{
+				Limiter: limit.Limiter{
+					ScaleDownLimitFactor:  2,
+					ScaleDownLimitMinimum: 0,
+				},
+			},
+			4,
+			&metric.Metric{
+				Spec: autoscaling.MetricSpec{
+					External: &autoscaling.ExternalMetricSource{
+						Target: autoscaling.MetricTarget{
+							AverageValue: k8sresource.NewQuantity(10, k8sresource.DecimalSI),
+						},
+					},
+				},
+				Prometheus: &metricprometheus.Metric{
+					Value:     0,
+					Timestamp: time.Unix(0, 0),
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			evaluation, err := test.evaluater.GetEvaluation(test.currentReplicas, test.gatheredMetric)
+			if !cmp.Equal(&err, &test.expectedErr, equateErrorMessage) {
+				t.Errorf("error mismatch (-want +got):\n%s", cmp.Diff(test.expectedErr, err, equateErrorMessage))
+				return
+			}
+			if !cmp.Equal(test.expected, evaluation) {
+				t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(test.expected, evaluation))
+			}
+		})
+	}
+}
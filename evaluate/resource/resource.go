@@ -33,6 +33,7 @@ import (
 
 	"github.com/jthomperoo/custom-pod-autoscaler/v2/evaluate"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
 	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
 )
@@ -42,35 +43,43 @@ type Evaluator interface {
 	GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
 }
 
-// Evaluate (resource) calculates a replica count evaluation, using the tolerance and calculater provided
+// Evaluate (resource) calculates a replica count evaluation, using the tolerance and calculater provided.
+// Unlike External/Object, neither ResourceMetricSource nor ContainerResourceMetricSource has a Metric
+// selector to carry a per-metric tolerance override in, so Tolerance always applies uniformly here; scoping a
+// resource metric to one container is already supported directly via ContainerResourceMetricSourceType's real
+// Container field rather than a synthetic override.
 type Evaluate struct {
 	Calculater calculate.Calculater
 	Tolerance  float64
+	limit.Limiter
 }
 
-// GetEvaluation calculates an evaluation based on the metric provided and the current number of replicas
+// GetEvaluation calculates an evaluation based on the metric provided and the current number of replicas.
 func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
-	if gatheredMetric.Spec.Resource.Target.AverageValue != nil {
+	resourceMetric := gatheredMetric.Resource
+	resourceTarget := gatheredMetric.Spec.Resource.Target
+
+	if resourceTarget.AverageValue != nil {
 		replicaCount := e.Calculater.GetPlainMetricReplicaCount(
-			gatheredMetric.Resource.PodMetricsInfo,
+			resourceMetric.PodMetricsInfo,
 			currentReplicas,
-			gatheredMetric.Spec.Resource.Target.AverageValue.MilliValue(),
-			gatheredMetric.Resource.ReadyPodCount,
-			gatheredMetric.Resource.MissingPods,
-			gatheredMetric.Resource.IgnoredPods,
+			resourceTarget.AverageValue.MilliValue(),
+			resourceMetric.ReadyPodCount,
+			resourceMetric.MissingPods,
+			resourceMetric.IgnoredPods,
 		)
 		return &evaluate.Evaluation{
-			TargetReplicas: replicaCount,
+			TargetReplicas: e.Limit(currentReplicas, replicaCount),
 		}, nil
 	}
 
-	if gatheredMetric.Spec.Resource.Target.AverageUtilization != nil {
-		metrics := gatheredMetric.Resource.PodMetricsInfo
-		requests := gatheredMetric.Resource.Requests
-		targetUtilization := *gatheredMetric.Spec.Resource.Target.AverageUtilization
-		ignoredPods := gatheredMetric.Resource.IgnoredPods
-		missingPods := gatheredMetric.Resource.MissingPods
-		readyPodCount := gatheredMetric.Resource.ReadyPodCount
+	if resourceTarget.AverageUtilization != nil {
+		metrics := resourceMetric.PodMetricsInfo
+		requests := resourceMetric.Requests
+		targetUtilization := *resourceTarget.AverageUtilization
+		ignoredPods := resourceMetric.IgnoredPods
+		missingPods := resourceMetric.MissingPods
+		readyPodCount := resourceMetric.ReadyPodCount
 
 		usageRatio, _, _, err := metricsclient.GetResourceUtilizationRatio(metrics, requests, targetUtilization)
 		if err != nil {
@@ -94,7 +103,7 @@ func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.M
 			targetReplicas := int32(math.Ceil(usageRatio * float64(readyPodCount)))
 			// if we don't have any unready or missing pods, we can calculate the new replica count now
 			return &evaluate.Evaluation{
-				TargetReplicas: targetReplicas,
+				TargetReplicas: e.Limit(currentReplicas, targetReplicas),
 			}, nil
 		}
 
@@ -138,7 +147,7 @@ func (e *Evaluate) GetEvaluation(currentReplicas int32, gatheredMetric *metric.M
 		// however many replicas factored into our calculation
 		targetReplicas := int32(math.Ceil(newUsageRatio * float64(len(metrics))))
 		return &evaluate.Evaluation{
-			TargetReplicas: targetReplicas,
+			TargetReplicas: e.Limit(currentReplicas, targetReplicas),
 		}, nil
 	}
 
@@ -23,11 +23,12 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/jthomperoo/custom-pod-autoscaler/evaluate"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/calculate"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/limit"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/evaluate/resource"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/fake"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
 	resourcemetric "github.com/jthomperoo/horizontal-pod-autoscaler/metric/resource"
-	"k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/api/autoscaling/v2"
 	k8sresource "k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
@@ -62,8 +63,8 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			3,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{},
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{},
 				},
 			},
 		},
@@ -81,9 +82,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			5,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageValue: k8sresource.NewMilliQuantity(50, k8sresource.DecimalSI),
 						},
 					},
@@ -104,9 +105,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			3,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(15),
 						},
 					},
@@ -130,9 +131,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			2,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(50),
 						},
 					},
@@ -166,9 +167,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			2,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(50),
 						},
 					},
@@ -202,9 +203,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			2,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(50),
 						},
 					},
@@ -238,9 +239,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			4,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(50),
 						},
 					},
@@ -279,9 +280,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			4,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(50),
 						},
 					},
@@ -320,9 +321,9 @@ func TestGetEvaluation(t *testing.T) {
 			0,
 			4,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(50),
 						},
 					},
@@ -366,9 +367,9 @@ func TestGetEvaluation(t *testing.T) {
 			0.5,
 			4,
 			&metric.Metric{
-				Spec: v2beta2.MetricSpec{
-					Resource: &v2beta2.ResourceMetricSource{
-						Target: v2beta2.MetricTarget{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
 							AverageUtilization: int32Ptr(50),
 						},
 					},
@@ -420,3 +421,113 @@ func TestGetEvaluation(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEvaluation_ScaleLimit(t *testing.T) {
+	var tests = []struct {
+		description           string
+		expected              *evaluate.Evaluation
+		rawTargetReplicas     int32
+		currentReplicas       int32
+		scaleUpLimitFactor    float64
+		scaleUpLimitMinimum   int32
+		scaleDownLimitFactor  float64
+		scaleDownLimitMinimum int32
+	}{
+		{
+			"Uncapped growth, within the factor limit",
+			&evaluate.Evaluation{
+				TargetReplicas: 15,
+			},
+			15,
+			10,
+			2,
+			4,
+			0,
+			0,
+		},
+		{
+			"Capped growth, hits the factor limit",
+			&evaluate.Evaluation{
+				TargetReplicas: 20,
+			},
+			30,
+			10,
+			2,
+			4,
+			0,
+			0,
+		},
+		{
+			"Capped growth, hits the minimum floor with few current replicas",
+			&evaluate.Evaluation{
+				TargetReplicas: 4,
+			},
+			10,
+			1,
+			2,
+			4,
+			0,
+			0,
+		},
+		{
+			"No-op, target already within the cap",
+			&evaluate.Evaluation{
+				TargetReplicas: 10,
+			},
+			10,
+			10,
+			2,
+			4,
+			0,
+			0,
+		},
+		{
+			"Capped shrinkage, hits the scale down factor limit",
+			&evaluate.Evaluation{
+				TargetReplicas: 5,
+			},
+			1,
+			10,
+			0,
+			0,
+			2,
+			4,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			evaluater := resource.Evaluate{
+				Calculater: &fake.Calculate{
+					GetPlainMetricReplicaCountReactor: func(metrics metrics.PodMetricsInfo, currentReplicas int32, targetUtilization, readyPodCount int64, missingPods, ignoredPods sets.String) int32 {
+						return test.rawTargetReplicas
+					},
+				},
+				Limiter: limit.Limiter{
+					ScaleUpLimitFactor:    test.scaleUpLimitFactor,
+					ScaleUpLimitMinimum:   test.scaleUpLimitMinimum,
+					ScaleDownLimitFactor:  test.scaleDownLimitFactor,
+					ScaleDownLimitMinimum: test.scaleDownLimitMinimum,
+				},
+			}
+			gatheredMetric := &metric.Metric{
+				Spec: v2.MetricSpec{
+					Resource: &v2.ResourceMetricSource{
+						Target: v2.MetricTarget{
+							AverageValue: k8sresource.NewMilliQuantity(50, k8sresource.DecimalSI),
+						},
+					},
+				},
+				Resource: &resourcemetric.Metric{
+					PodMetricsInfo: metrics.PodMetricsInfo{},
+				},
+			}
+			evaluation, err := evaluater.GetEvaluation(test.currentReplicas, gatheredMetric)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !cmp.Equal(test.expected, evaluation) {
+				t.Errorf("evaluation mismatch (-want +got):\n%s", cmp.Diff(test.expected, evaluation))
+			}
+		})
+	}
+}
@@ -44,3 +44,23 @@ type ExternalEvaluater struct {
 func (f *ExternalEvaluater) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
 	return f.GetEvaluationReactor(currentReplicas, gatheredMetric)
 }
+
+// ContainerResourceEvaluater (fake) provides a way to insert functionality into a ContainerResourceEvaluater
+type ContainerResourceEvaluater struct {
+	GetEvaluationReactor func(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
+}
+
+// GetEvaluation calls the fake Evaluater function
+func (f *ContainerResourceEvaluater) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	return f.GetEvaluationReactor(currentReplicas, gatheredMetric)
+}
+
+// PrometheusEvaluater (fake) provides a way to insert functionality into a PrometheusEvaluater
+type PrometheusEvaluater struct {
+	GetEvaluationReactor func(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error)
+}
+
+// GetEvaluation calls the fake Evaluater function
+func (f *PrometheusEvaluater) GetEvaluation(currentReplicas int32, gatheredMetric *metric.Metric) (*evaluate.Evaluation, error) {
+	return f.GetEvaluationReactor(currentReplicas, gatheredMetric)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package annotation synthesizes autoscaling.MetricSpecs from annotations on the scale target, rather than
+// requiring them to be supplied directly in the CPA configuration, following the pattern used by Banzai's
+// hpa-operator (for example "cpu.hpa.autoscaling.custompodautoscaler.io/targetAverageUtilization: 70").
+package annotation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	autoscaling "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+)
+
+// DefaultPrefix is the annotation prefix used when none is configured on an Infer.
+const DefaultPrefix = "hpa.autoscaling.custompodautoscaler.io"
+
+const (
+	keyTargetAverageUtilization = "targetAverageUtilization"
+	keyTargetAverageValue       = "targetAverageValue"
+	keyQuery                    = "query"
+	keyPrometheusQuery          = "prometheusQuery"
+)
+
+// resourceNames are the metric names recognised as built-in Kubernetes resource metrics. Any other annotated
+// name is treated as an External metric, for example a Prometheus-adapter-backed query.
+var resourceNames = map[string]v1.ResourceName{
+	"cpu":    v1.ResourceCPU,
+	"memory": v1.ResourceMemory,
+}
+
+// Inferer synthesizes MetricSpecs from a target's annotations.
+type Inferer interface {
+	Infer(target runtime.Object, annotations map[string]string) []autoscaling.MetricSpec
+}
+
+// Infer (annotation) parses resource, external and prometheus MetricSpecs out of annotations namespaced under
+// Prefix, in the form "<metric>.<Prefix>/<key>". A "prometheusQuery" key synthesizes a metric/prometheus
+// MetricSpec, executing the annotation's value as a PromQL query directly against Prometheus rather than
+// through any Kubernetes metrics API. A malformed annotation is skipped rather than aborting inference for the
+// rest of the target's annotations, and is reported as a Warning event against target if Recorder is set.
+type Infer struct {
+	// Prefix namespaces the annotations this Infer looks for. Defaults to DefaultPrefix if empty.
+	Prefix string
+	// Recorder, if set, is used to surface a Warning event against target for each annotation that fails to
+	// parse, rather than silently dropping it.
+	Recorder record.EventRecorder
+}
+
+type inferredMetric struct {
+	averageUtilization *int32
+	averageValue       string
+	query              string
+	prometheusQuery    string
+}
+
+// Infer reads every annotation under i.Prefix and synthesizes the equivalent MetricSpecs. Annotations that
+// don't match the prefix are ignored; annotations that do match but fail to parse are skipped and reported as
+// a Warning event against target.
+func (i *Infer) Infer(target runtime.Object, annotations map[string]string) []autoscaling.MetricSpec {
+	prefix := i.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	suffix := "." + prefix + "/"
+
+	metrics := map[string]*inferredMetric{}
+	var order []string
+	for key, value := range annotations {
+		idx := strings.Index(key, suffix)
+		if idx <= 0 {
+			continue
+		}
+		metricName := key[:idx]
+		field := key[idx+len(suffix):]
+
+		entry, ok := metrics[metricName]
+		if !ok {
+			entry = &inferredMetric{}
+			metrics[metricName] = entry
+			order = append(order, metricName)
+		}
+
+		switch field {
+		case keyTargetAverageUtilization:
+			utilization, err := strconv.Atoi(value)
+			if err != nil {
+				i.invalid(target, key, value, fmt.Errorf("not a valid integer percentage: %v", err))
+				continue
+			}
+			u := int32(utilization)
+			entry.averageUtilization = &u
+		case keyTargetAverageValue:
+			entry.averageValue = value
+		case keyQuery:
+			entry.query = value
+		case keyPrometheusQuery:
+			entry.prometheusQuery = value
+		default:
+			i.invalid(target, key, value, fmt.Errorf("unrecognised metric annotation key %q", field))
+		}
+	}
+
+	sort.Strings(order)
+
+	var specs []autoscaling.MetricSpec
+	for _, metricName := range order {
+		spec, err := i.buildSpec(metricName, metrics[metricName])
+		if err != nil {
+			i.invalid(target, metricName, "", err)
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+func (i *Infer) buildSpec(metricName string, entry *inferredMetric) (autoscaling.MetricSpec, error) {
+	if entry.prometheusQuery != "" {
+		target, err := buildTarget(entry.averageUtilization, entry.averageValue)
+		if err != nil {
+			return autoscaling.MetricSpec{}, err
+		}
+		return autoscaling.MetricSpec{
+			Type: prometheus.MetricSourceType,
+			External: &autoscaling.ExternalMetricSource{
+				Metric: autoscaling.MetricIdentifier{Name: entry.prometheusQuery},
+				Target: target,
+			},
+		}, nil
+	}
+
+	if resourceName, ok := resourceNames[metricName]; ok {
+		target, err := buildTarget(entry.averageUtilization, entry.averageValue)
+		if err != nil {
+			return autoscaling.MetricSpec{}, err
+		}
+		return autoscaling.MetricSpec{
+			Type: autoscaling.ResourceMetricSourceType,
+			Resource: &autoscaling.ResourceMetricSource{
+				Name:   resourceName,
+				Target: target,
+			},
+		}, nil
+	}
+
+	if entry.averageUtilization != nil {
+		return autoscaling.MetricSpec{}, fmt.Errorf("external metric %q cannot use %s, only %s is supported", metricName, keyTargetAverageUtilization, keyTargetAverageValue)
+	}
+
+	target, err := buildTarget(nil, entry.averageValue)
+	if err != nil {
+		return autoscaling.MetricSpec{}, err
+	}
+
+	var metricSelector *metav1.LabelSelector
+	if entry.query != "" {
+		metricSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{"query": entry.query},
+		}
+	}
+
+	return autoscaling.MetricSpec{
+		Type: autoscaling.ExternalMetricSourceType,
+		External: &autoscaling.ExternalMetricSource{
+			Metric: autoscaling.MetricIdentifier{
+				Name:     metricName,
+				Selector: metricSelector,
+			},
+			Target: target,
+		},
+	}, nil
+}
+
+func buildTarget(averageUtilization *int32, averageValue string) (autoscaling.MetricTarget, error) {
+	if averageUtilization == nil && averageValue == "" {
+		return autoscaling.MetricTarget{}, fmt.Errorf("neither %s nor %s was set", keyTargetAverageUtilization, keyTargetAverageValue)
+	}
+	if averageUtilization != nil && averageValue != "" {
+		return autoscaling.MetricTarget{}, fmt.Errorf("only one of %s or %s may be set", keyTargetAverageUtilization, keyTargetAverageValue)
+	}
+	if averageUtilization != nil {
+		return autoscaling.MetricTarget{
+			Type:               autoscaling.UtilizationMetricType,
+			AverageUtilization: averageUtilization,
+		}, nil
+	}
+	quantity, err := resource.ParseQuantity(averageValue)
+	if err != nil {
+		return autoscaling.MetricTarget{}, fmt.Errorf("invalid %s %q: %v", keyTargetAverageValue, averageValue, err)
+	}
+	return autoscaling.MetricTarget{
+		Type:         autoscaling.AverageValueMetricType,
+		AverageValue: &quantity,
+	}, nil
+}
+
+func (i *Infer) invalid(target runtime.Object, annotation, value string, err error) {
+	if i.Recorder == nil {
+		return
+	}
+	i.Recorder.Eventf(target, v1.EventTypeWarning, "InvalidMetricAnnotation", "failed to parse annotation %q (value %q): %v", annotation, value, err)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotation_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/annotation"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestInfer(t *testing.T) {
+	var tests = []struct {
+		description string
+		infer       *annotation.Infer
+		annotations map[string]string
+		expected    []autoscaling.MetricSpec
+	}{
+		{
+			description: "no matching annotations",
+			infer:       &annotation.Infer{},
+			annotations: map[string]string{"unrelated": "value"},
+			expected:    nil,
+		},
+		{
+			description: "cpu average utilization with default prefix",
+			infer:       &annotation.Infer{},
+			annotations: map[string]string{
+				"cpu." + annotation.DefaultPrefix + "/targetAverageUtilization": "70",
+			},
+			expected: []autoscaling.MetricSpec{
+				{
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name: v1.ResourceCPU,
+						Target: autoscaling.MetricTarget{
+							Type:               autoscaling.UtilizationMetricType,
+							AverageUtilization: int32Ptr(70),
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "memory average value with custom prefix",
+			infer:       &annotation.Infer{Prefix: "custom.example.com"},
+			annotations: map[string]string{
+				"memory.custom.example.com/targetAverageValue": "500Mi",
+			},
+			expected: []autoscaling.MetricSpec{
+				{
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name: v1.ResourceMemory,
+						Target: autoscaling.MetricTarget{
+							Type:         autoscaling.AverageValueMetricType,
+							AverageValue: resourceQuantity("500Mi"),
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "external metric with query selector",
+			infer:       &annotation.Infer{},
+			annotations: map[string]string{
+				"queue_length." + annotation.DefaultPrefix + "/targetAverageValue": "100",
+				"queue_length." + annotation.DefaultPrefix + "/query":              "sum(queue_length)",
+			},
+			expected: []autoscaling.MetricSpec{
+				{
+					Type: autoscaling.ExternalMetricSourceType,
+					External: &autoscaling.ExternalMetricSource{
+						Metric: autoscaling.MetricIdentifier{
+							Name: "queue_length",
+							Selector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"query": "sum(queue_length)"},
+							},
+						},
+						Target: autoscaling.MetricTarget{
+							Type:         autoscaling.AverageValueMetricType,
+							AverageValue: resourceQuantity("100"),
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "prometheus query metric",
+			infer:       &annotation.Infer{},
+			annotations: map[string]string{
+				"checkout_latency." + annotation.DefaultPrefix + "/targetAverageValue": "100",
+				"checkout_latency." + annotation.DefaultPrefix + "/prometheusQuery":    "histogram_quantile(0.99, checkout_latency_seconds_bucket)",
+			},
+			expected: []autoscaling.MetricSpec{
+				{
+					Type: prometheus.MetricSourceType,
+					External: &autoscaling.ExternalMetricSource{
+						Metric: autoscaling.MetricIdentifier{
+							Name: "histogram_quantile(0.99, checkout_latency_seconds_bucket)",
+						},
+						Target: autoscaling.MetricTarget{
+							Type:         autoscaling.AverageValueMetricType,
+							AverageValue: resourceQuantity("100"),
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "invalid utilization is skipped",
+			infer:       &annotation.Infer{},
+			annotations: map[string]string{
+				"cpu." + annotation.DefaultPrefix + "/targetAverageUtilization": "not-a-number",
+			},
+			expected: nil,
+		},
+		{
+			description: "external metric with utilization target is skipped",
+			infer:       &annotation.Infer{},
+			annotations: map[string]string{
+				"queue_length." + annotation.DefaultPrefix + "/targetAverageUtilization": "70",
+			},
+			expected: nil,
+		},
+		{
+			description: "multiple metrics are returned sorted by metric name",
+			infer:       &annotation.Infer{},
+			annotations: map[string]string{
+				"memory." + annotation.DefaultPrefix + "/targetAverageUtilization": "80",
+				"cpu." + annotation.DefaultPrefix + "/targetAverageUtilization":    "70",
+			},
+			expected: []autoscaling.MetricSpec{
+				{
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name: v1.ResourceCPU,
+						Target: autoscaling.MetricTarget{
+							Type:               autoscaling.UtilizationMetricType,
+							AverageUtilization: int32Ptr(70),
+						},
+					},
+				},
+				{
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name: v1.ResourceMemory,
+						Target: autoscaling.MetricTarget{
+							Type:               autoscaling.UtilizationMetricType,
+							AverageUtilization: int32Ptr(80),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			result := test.infer.Infer(nil, test.annotations)
+			if !cmp.Equal(test.expected, result) {
+				t.Errorf("metric specs mismatch (-want +got):\n%s", cmp.Diff(test.expected, result))
+			}
+		})
+	}
+}
+
+func resourceQuantity(value string) *resource.Quantity {
+	quantity := resource.MustParse(value)
+	return &quantity
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/labels"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultCacheTTL is how long a gathered metric is served from the CachingGatherer's cache before being
+// re-fetched, when CachingGatherer.TTL is left unset. It matches the HPA controller's default sync period, so a
+// CachingGatherer configured with defaults doesn't serve metrics any staler than an uncached gatherer polled once
+// per sync period would.
+const defaultCacheTTL = 15 * time.Second
+
+var (
+	cacheHitsTotal = promauto.NewCounterVec(promclient.CounterOpts{
+		Namespace: "horizontal_pod_autoscaler",
+		Subsystem: "metric_cache",
+		Name:      "hits_total",
+		Help:      "Number of CachingGatherer metric gathers served from cache, by metric source type.",
+	}, []string{"metric_type"})
+
+	cacheMissesTotal = promauto.NewCounterVec(promclient.CounterOpts{
+		Namespace: "horizontal_pod_autoscaler",
+		Subsystem: "metric_cache",
+		Name:      "misses_total",
+		Help:      "Number of CachingGatherer metric gathers that missed the cache and were fetched, by metric source type.",
+	}, []string{"metric_type"})
+
+	gatherDurationSeconds = promauto.NewHistogramVec(promclient.HistogramOpts{
+		Namespace: "horizontal_pod_autoscaler",
+		Subsystem: "metric_cache",
+		Name:      "gather_duration_seconds",
+		Help:      "Time taken to gather a single metric spec on a cache miss, by metric source type.",
+		Buckets:   promclient.DefBuckets,
+	}, []string{"metric_type"})
+)
+
+// cacheEntry is a single cached gather outcome, good until expiresAt.
+type cacheEntry struct {
+	metric    *CombinedMetric
+	err       error
+	expiresAt time.Time
+}
+
+// CachingGatherer wraps a Gatherer, adding a short-TTL cache and in-flight request deduplication in front of
+// per-spec metric gathering, and gathers independent specs concurrently rather than one at a time. This targets
+// HPA controllers polling many scale targets on a fixed interval, where GetMetrics would otherwise make one
+// synchronous round trip per MetricSpec to the underlying metrics backend (metrics-server, Prometheus, etc.) on
+// every poll, even though the same metric is frequently requested again moments later.
+type CachingGatherer struct {
+	Gatherer *Gatherer
+	// TTL is how long a gathered metric is served from cache before being re-fetched. Zero uses defaultCacheTTL.
+	TTL time.Duration
+	// MaxConcurrency bounds how many specs are gathered concurrently within a single GetMetrics call. Zero means
+	// unbounded (every spec in the call is gathered concurrently).
+	MaxConcurrency int
+
+	group   singleflight.Group
+	cacheMu sync.Mutex
+	cache   map[string]cacheEntry
+}
+
+// GetMetrics gathers the provided specs the same way Gatherer.GetMetrics does - same signature, same
+// tolerate-partial-failure behaviour - but serves cached results where available and gathers cache misses
+// concurrently, deduplicating identical in-flight gathers via singleflight.
+func (c *CachingGatherer) GetMetrics(scaleTargetRef autoscaling.CrossVersionObjectReference, scaleObj *autoscalingv1.Scale, specs []autoscaling.MetricSpec, namespace string) ([]*CombinedMetric, error) {
+	currentReplicas := scaleObj.Spec.Replicas
+
+	if c.Gatherer.AnnotationInferer != nil {
+		specs = append(specs, c.Gatherer.AnnotationInferer.Infer(scaleObj, scaleObj.Annotations)...)
+	}
+
+	selector, err := labels.Parse(scaleObj.Status.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse scale subresource selector for %s %s/%s: %v", scaleTargetRef.Kind, namespace, scaleTargetRef.Name, err)
+	}
+
+	results := make([]*CombinedMetric, len(specs))
+	errs := make([]error, len(specs))
+
+	var g errgroup.Group
+	if c.MaxConcurrency > 0 {
+		g.SetLimit(c.MaxConcurrency)
+	}
+
+	for i, spec := range specs {
+		i, spec := i, spec
+		g.Go(func() error {
+			results[i], errs[i] = c.getMetricCached(currentReplicas, spec, namespace, selector)
+			return nil
+		})
+	}
+	// Worker funcs above never return a non-nil error; per-spec failures are collected in errs instead, so that
+	// one bad spec doesn't cancel the sibling gathers still in flight. The returned error is always nil.
+	_ = g.Wait()
+
+	var combinedMetrics []*CombinedMetric
+	var invalidMetricError error
+	invalidMetricsCount := 0
+	for i := range specs {
+		if errs[i] != nil {
+			if invalidMetricsCount <= 0 {
+				invalidMetricError = errs[i]
+			}
+			invalidMetricsCount++
+			continue
+		}
+		combinedMetrics = append(combinedMetrics, results[i])
+	}
+
+	if invalidMetricsCount >= len(specs) {
+		return nil, fmt.Errorf("invalid metrics (%v invalid out of %v), first error is: %v", invalidMetricsCount, len(specs), invalidMetricError)
+	}
+
+	return combinedMetrics, nil
+}
+
+// getMetricCached serves spec's metric from cache if present and unexpired, otherwise gathers it through
+// c.Gatherer - deduplicating concurrent identical gathers via singleflight - and populates the cache for
+// subsequent calls.
+func (c *CachingGatherer) getMetricCached(currentReplicas int32, spec autoscaling.MetricSpec, namespace string, selector labels.Selector) (*CombinedMetric, error) {
+	metricType := string(spec.Type)
+	key, err := cacheKey(namespace, selector, spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build cache key for %s metric: %v", metricType, err)
+	}
+
+	if entry, ok := c.lookup(key); ok {
+		cacheHitsTotal.WithLabelValues(metricType).Inc()
+		return entry.metric, entry.err
+	}
+	cacheMissesTotal.WithLabelValues(metricType).Inc()
+
+	type gathered struct {
+		metric *CombinedMetric
+		err    error
+	}
+
+	v, _, _ := c.group.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		metric, err := c.Gatherer.getMetric(currentReplicas, spec, namespace, selector)
+		gatherDurationSeconds.WithLabelValues(metricType).Observe(time.Since(start).Seconds())
+		c.store(key, metric, err)
+		return gathered{metric, err}, nil
+	})
+
+	result := v.(gathered)
+	return result.metric, result.err
+}
+
+// cacheKey builds a cache key uniquely identifying a gather of spec for namespace/selector. spec is JSON-marshaled
+// rather than formatted with %+v: MetricSpec carries pointer fields (Resource, Object, Pods, etc.), and %+v on a
+// pointer renders its address rather than the value it points to, so two independently-decoded but
+// field-for-field-identical specs (as main.go produces on every invocation) would hash to different keys and
+// always miss the cache.
+func cacheKey(namespace string, selector labels.Selector, spec autoscaling.MetricSpec) (string, error) {
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%s", namespace, selector.String(), encoded), nil
+}
+
+func (c *CachingGatherer) lookup(key string) (cacheEntry, bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	entry, ok := c.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *CachingGatherer) store(key string, metric *CombinedMetric, err error) {
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if c.cache == nil {
+		c.cache = make(map[string]cacheEntry)
+	}
+	c.cache[key] = cacheEntry{metric: metric, err: err, expiresAt: time.Now().Add(ttl)}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+)
+
+func TestCachingGatherer_GetMetrics(t *testing.T) {
+	t.Run("repeated gather within TTL only calls the underlying gatherer once", func(t *testing.T) {
+		var calls int32
+		fakeClient := &fakeMetricsClient{
+			getResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+				atomic.AddInt32(&calls, 1)
+				return metricsclient.PodMetricsInfo{
+					"pod-1": metricsclient.PodMetric{Value: 50},
+				}, time.Unix(0, 0), nil
+			},
+		}
+
+		caching := &metric.CachingGatherer{
+			Gatherer: &metric.Gatherer{
+				MetricsClient: fakeClient,
+				PodLister:     statusPodLister(statusReadyPod("pod-1", "100m")),
+			},
+			TTL: time.Minute,
+		}
+
+		targetUtilization := int32(80)
+		specs := []autoscaling.MetricSpec{
+			{
+				Type: autoscaling.ResourceMetricSourceType,
+				Resource: &autoscaling.ResourceMetricSource{
+					Name: v1.ResourceCPU,
+					Target: autoscaling.MetricTarget{
+						Type:               autoscaling.UtilizationMetricType,
+						AverageUtilization: &targetUtilization,
+					},
+				},
+			},
+		}
+		scaleObj := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}}
+		scaleTargetRef := autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"}
+
+		for i := 0; i < 3; i++ {
+			metrics, err := caching.GetMetrics(scaleTargetRef, scaleObj, specs, "test-namespace")
+			if err != nil {
+				t.Fatalf("unexpected error on call %d: %v", i, err)
+			}
+			if len(metrics) != 1 {
+				t.Fatalf("expected 1 metric on call %d, got %d", i, len(metrics))
+			}
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("expected the underlying gatherer to be called once across 3 gathers within TTL, got %d calls", got)
+		}
+	})
+
+	t.Run("independently-allocated but equal specs still hit the cache", func(t *testing.T) {
+		var calls int32
+		fakeClient := &fakeMetricsClient{
+			getResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+				atomic.AddInt32(&calls, 1)
+				return metricsclient.PodMetricsInfo{
+					"pod-1": metricsclient.PodMetric{Value: 50},
+				}, time.Unix(0, 0), nil
+			},
+		}
+
+		caching := &metric.CachingGatherer{
+			Gatherer: &metric.Gatherer{
+				MetricsClient: fakeClient,
+				PodLister:     statusPodLister(statusReadyPod("pod-1", "100m")),
+			},
+			TTL: time.Minute,
+		}
+
+		// A fresh ResourceMetricSource is built on each call below, the same way main.go decodes a brand new
+		// MetricSpec (with its own pointer fields) on every invocation - the cache key must be based on the
+		// pointed-to values, not the pointers themselves, for this to hit.
+		newSpecs := func() []autoscaling.MetricSpec {
+			targetUtilization := int32(80)
+			return []autoscaling.MetricSpec{
+				{
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name: v1.ResourceCPU,
+						Target: autoscaling.MetricTarget{
+							Type:               autoscaling.UtilizationMetricType,
+							AverageUtilization: &targetUtilization,
+						},
+					},
+				},
+			}
+		}
+		scaleObj := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}}
+		scaleTargetRef := autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"}
+
+		for i := 0; i < 3; i++ {
+			metrics, err := caching.GetMetrics(scaleTargetRef, scaleObj, newSpecs(), "test-namespace")
+			if err != nil {
+				t.Fatalf("unexpected error on call %d: %v", i, err)
+			}
+			if len(metrics) != 1 {
+				t.Fatalf("expected 1 metric on call %d, got %d", i, len(metrics))
+			}
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("expected the underlying gatherer to be called once across 3 gathers of equal but independently-allocated specs, got %d calls", got)
+		}
+	})
+
+	t.Run("expired cache entry triggers a re-gather", func(t *testing.T) {
+		var calls int32
+		fakeClient := &fakeMetricsClient{
+			getResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+				atomic.AddInt32(&calls, 1)
+				return metricsclient.PodMetricsInfo{
+					"pod-1": metricsclient.PodMetric{Value: 50},
+				}, time.Unix(0, 0), nil
+			},
+		}
+
+		caching := &metric.CachingGatherer{
+			Gatherer: &metric.Gatherer{
+				MetricsClient: fakeClient,
+				PodLister:     statusPodLister(statusReadyPod("pod-1", "100m")),
+			},
+			TTL: time.Millisecond,
+		}
+
+		targetUtilization := int32(80)
+		specs := []autoscaling.MetricSpec{
+			{
+				Type: autoscaling.ResourceMetricSourceType,
+				Resource: &autoscaling.ResourceMetricSource{
+					Name: v1.ResourceCPU,
+					Target: autoscaling.MetricTarget{
+						Type:               autoscaling.UtilizationMetricType,
+						AverageUtilization: &targetUtilization,
+					},
+				},
+			},
+		}
+		scaleObj := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}}
+		scaleTargetRef := autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"}
+
+		if _, err := caching.GetMetrics(scaleTargetRef, scaleObj, specs, "test-namespace"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err := caching.GetMetrics(scaleTargetRef, scaleObj, specs, "test-namespace"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Errorf("expected the underlying gatherer to be called twice once the cache entry expired, got %d calls", got)
+		}
+	})
+
+	t.Run("partial failure keeps positional alignment across concurrent gathers", func(t *testing.T) {
+		fakeClient := &fakeMetricsClient{
+			getResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+				return metricsclient.PodMetricsInfo{
+					"pod-1": metricsclient.PodMetric{Value: 50},
+				}, time.Unix(0, 0), nil
+			},
+		}
+
+		caching := &metric.CachingGatherer{
+			Gatherer: &metric.Gatherer{
+				MetricsClient: fakeClient,
+				PodLister:     statusPodLister(statusReadyPod("pod-1", "100m")),
+			},
+			MaxConcurrency: 1,
+		}
+
+		targetUtilization := int32(80)
+		specs := []autoscaling.MetricSpec{
+			{Type: "invalid"},
+			{
+				Type: autoscaling.ResourceMetricSourceType,
+				Resource: &autoscaling.ResourceMetricSource{
+					Name: v1.ResourceCPU,
+					Target: autoscaling.MetricTarget{
+						Type:               autoscaling.UtilizationMetricType,
+						AverageUtilization: &targetUtilization,
+					},
+				},
+			},
+		}
+		scaleObj := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}}
+		scaleTargetRef := autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"}
+
+		metrics, err := caching.GetMetrics(scaleTargetRef, scaleObj, specs, "test-namespace")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(metrics) != 1 {
+			t.Fatalf("expected the single valid metric to be returned, got %d", len(metrics))
+		}
+		if metrics[0].Spec.Type != autoscaling.ResourceMetricSourceType {
+			t.Errorf("expected the returned metric to be the resource metric, got %+v", metrics[0].Spec)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"fmt"
+	"time"
+
+	autoscaling "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+	custommetricsclient "k8s.io/metrics/pkg/client/custom_metrics"
+	externalmetricsclient "k8s.io/metrics/pkg/client/external_metrics"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/external"
+)
+
+// MetricsClient describes how the Gatherer retrieves raw metric values. It is a superset of
+// k8s.io/kubernetes/pkg/controller/podautoscaler/metrics.MetricsClient with GetContainerResourceMetric added for
+// ContainerResource metric sources. Gatherer depends on this interface rather than a concrete client so that
+// backends other than the standard metrics/custom-metrics/external-metrics adapter APIs can be plugged in, such as
+// a client that queries Prometheus directly (see the prometheusclient package).
+type MetricsClient interface {
+	GetResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error)
+	GetContainerResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error)
+	GetRawMetric(metricName string, namespace string, selector labels.Selector, metricSelector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error)
+	GetObjectMetric(metricName string, namespace string, objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) (int64, time.Time, error)
+	GetExternalMetric(metricName string, namespace string, selector labels.Selector) ([]int64, time.Time, error)
+}
+
+// metricsClientAdapter adapts the upstream metricsclient.MetricsClient to the local MetricsClient interface. Its
+// GetContainerResourceMetric always returns an error, since the upstream client predates
+// ContainerResource support; gatherers that need ContainerResource metrics should be configured with a
+// MetricsClient that implements GetContainerResourceMetric directly instead of this adapter.
+type metricsClientAdapter struct {
+	metricsclient.MetricsClient
+}
+
+// NewMetricsClientAdapter wraps an upstream metricsclient.MetricsClient so it satisfies the local MetricsClient
+// interface, for callers that don't need ContainerResource metric support.
+func NewMetricsClientAdapter(client metricsclient.MetricsClient) MetricsClient {
+	return metricsClientAdapter{client}
+}
+
+func (a metricsClientAdapter) GetContainerResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return metricsclient.PodMetricsInfo{}, time.Time{}, fmt.Errorf("ContainerResource metrics are not supported by this MetricsClient")
+}
+
+// NewCombined builds a Gatherer wired for every standard metric source type directly from the three Kubernetes
+// metrics API clients (resource, custom and external metrics), the same combination kube-controller-manager wires
+// up in startHPAControllerWithRESTClient, without requiring the caller to build their own MetricsClient adapter.
+// The external metrics client is additionally wired in directly as External, bypassing the combined MetricsClient
+// for that metric source type. ContainerResource specs are the exception: the returned Gatherer's default
+// ContainerResource gatherer goes through the adapted MetricsClient, which returns an error for them.
+func NewCombined(
+	resourceClient resourceclient.PodMetricsesGetter,
+	customMetricsClient custommetricsclient.CustomMetricsClient,
+	externalMetricsClient externalmetricsclient.ExternalMetricsClient,
+	podLister corelisters.PodLister,
+	cpuInitializationPeriod time.Duration,
+	memoryInitializationPeriod time.Duration,
+	delayOfInitialReadinessStatus time.Duration,
+) *Gatherer {
+	return &Gatherer{
+		MetricsClient: NewMetricsClientAdapter(metricsclient.NewRESTMetricsClient(
+			resourceClient,
+			customMetricsClient,
+			externalMetricsClient,
+		)),
+		PodLister:                     podLister,
+		CPUInitializationPeriod:       cpuInitializationPeriod,
+		MemoryInitializationPeriod:    memoryInitializationPeriod,
+		DelayOfInitialReadinessStatus: delayOfInitialReadinessStatus,
+		External:                      external.NewGatherer(externalMetricsClient, podLister),
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+Modifications Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+
+Modified to split up evaluations and metric gathering to work with the
+Custom Pod Autoscaler framework.
+Original source:
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/horizontal.go
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/replica_calculator.go
+*/
+
+package containerresource
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+)
+
+// Gatherer (ContainerResource) allows retrieval of a resource metric scoped to a single named container.
+type Gatherer interface {
+	GetMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (*Metric, error)
+	GetRawMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (*Metric, error)
+}
+
+// MetricsClient describes how Gather retrieves the raw metric values it needs, scoped to a single named
+// container within each pod rather than summed across the whole pod.
+type MetricsClient interface {
+	GetContainerResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error)
+}
+
+// Metric (ContainerResource) is a resource metric known to Kubernetes (CPU or memory), scoped to a single named
+// container within each pod in the current scale target, rather than summed across every container in the pod.
+type Metric struct {
+	PodMetricsInfo metricsclient.PodMetricsInfo
+	Requests       map[string]int64
+	ReadyPodCount  int64
+	IgnoredPods    sets.String
+	MissingPods    sets.String
+	TotalPods      int
+	Timestamp      time.Time
+}
+
+// Gather (ContainerResource) provides functionality for retrieving metrics for container resource metric specs.
+type Gather struct {
+	MetricsClient           MetricsClient
+	PodLister               corelisters.PodLister
+	CPUInitializationPeriod time.Duration
+	// MemoryInitializationPeriod is CPUInitializationPeriod's counterpart for memory metrics: see
+	// metric.Gatherer.MemoryInitializationPeriod for the rationale.
+	MemoryInitializationPeriod    time.Duration
+	DelayOfInitialReadinessStatus time.Duration
+}
+
+// GetMetric retrieves a resource metric scoped to a single named container in each pod matched by selector.
+func (c *Gather) GetMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (*Metric, error) {
+	// Get metrics, scoped to the target container rather than summed across the whole pod
+	metrics, timestamp, err := c.MetricsClient.GetContainerResourceMetric(resource, namespace, selector, container)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get metrics for resource %s container %s: %v", resource, container, err)
+	}
+
+	// Get pods
+	podList, err := c.PodLister.Pods(namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pods while calculating replica count: %v", err)
+	}
+
+	totalPods := len(podList)
+	if totalPods == 0 {
+		return nil, fmt.Errorf("No pods returned by selector while calculating replica count")
+	}
+
+	// Remove missing pod metrics
+	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, resource, c.CPUInitializationPeriod, c.MemoryInitializationPeriod, c.DelayOfInitialReadinessStatus)
+	removeMetricsForPods(metrics, ignoredPods)
+
+	// Calculate requests for the target container only, per pod
+	requests, err := calculateContainerRequests(podList, resource, container)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metric{
+		PodMetricsInfo: metrics,
+		Requests:       requests,
+		ReadyPodCount:  int64(readyPodCount),
+		IgnoredPods:    ignoredPods,
+		MissingPods:    missingPods,
+		TotalPods:      totalPods,
+		Timestamp:      timestamp,
+	}, nil
+}
+
+// GetRawMetric retrieves a resource metric scoped to a single named container in each pod matched by selector,
+// without computing each pod's requests, for use with an AverageValue target rather than AverageUtilization.
+func (c *Gather) GetRawMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (*Metric, error) {
+	// Get metrics, scoped to the target container rather than summed across the whole pod
+	metrics, timestamp, err := c.MetricsClient.GetContainerResourceMetric(resource, namespace, selector, container)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get metrics for resource %s container %s: %v", resource, container, err)
+	}
+
+	// Get pods
+	podList, err := c.PodLister.Pods(namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pods while calculating replica count: %v", err)
+	}
+
+	totalPods := len(podList)
+	if totalPods == 0 {
+		return nil, fmt.Errorf("No pods returned by selector while calculating replica count")
+	}
+
+	// Remove missing pod metrics
+	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, resource, c.CPUInitializationPeriod, c.MemoryInitializationPeriod, c.DelayOfInitialReadinessStatus)
+	removeMetricsForPods(metrics, ignoredPods)
+
+	return &Metric{
+		PodMetricsInfo: metrics,
+		ReadyPodCount:  int64(readyPodCount),
+		IgnoredPods:    ignoredPods,
+		MissingPods:    missingPods,
+		TotalPods:      totalPods,
+		Timestamp:      timestamp,
+	}, nil
+}
+
+func calculateContainerRequests(pods []*v1.Pod, resource v1.ResourceName, container string) (map[string]int64, error) {
+	requests := make(map[string]int64, len(pods))
+	for _, pod := range pods {
+		found := false
+		for _, podContainer := range pod.Spec.Containers {
+			if podContainer.Name != container {
+				continue
+			}
+			found = true
+			containerRequest, ok := podContainer.Resources.Requests[resource]
+			if !ok {
+				return nil, fmt.Errorf("missing request for %s in container %s", resource, container)
+			}
+			requests[pod.Name] = containerRequest.MilliValue()
+		}
+		if !found {
+			return nil, fmt.Errorf("container %s not present in pod %s", container, pod.Name)
+		}
+	}
+	return requests, nil
+}
+
+func groupPods(pods []*v1.Pod, metrics metricsclient.PodMetricsInfo, resource v1.ResourceName, cpuInitializationPeriod, memoryInitializationPeriod, delayOfInitialReadinessStatus time.Duration) (readyPodCount int, ignoredPods sets.String, missingPods sets.String) {
+	missingPods = sets.NewString()
+	ignoredPods = sets.NewString()
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		metric, found := metrics[pod.Name]
+		if !found {
+			missingPods.Insert(pod.Name)
+			continue
+		}
+		if resource == v1.ResourceCPU || resource == v1.ResourceMemory {
+			var initializationPeriod time.Duration
+			if resource == v1.ResourceCPU {
+				initializationPeriod = cpuInitializationPeriod
+			} else {
+				initializationPeriod = memoryInitializationPeriod
+			}
+
+			var ignorePod bool
+			_, condition := podutil.GetPodCondition(&pod.Status, v1.PodReady)
+			if condition == nil || pod.Status.StartTime == nil {
+				ignorePod = true
+			} else {
+				if pod.Status.StartTime.Add(initializationPeriod).After(time.Now()) {
+					ignorePod = condition.Status == v1.ConditionFalse || metric.Timestamp.Before(condition.LastTransitionTime.Time.Add(metric.Window))
+				} else {
+					ignorePod = condition.Status == v1.ConditionFalse && pod.Status.StartTime.Add(delayOfInitialReadinessStatus).After(condition.LastTransitionTime.Time)
+				}
+			}
+			if ignorePod {
+				ignoredPods.Insert(pod.Name)
+				continue
+			}
+		}
+		readyPodCount++
+	}
+	return
+}
+
+func removeMetricsForPods(metrics metricsclient.PodMetricsInfo, pods sets.String) {
+	for _, pod := range pods.UnsortedList() {
+		delete(metrics, pod)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerresource_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	corelisters "k8s.io/client-go/listers/core/v1"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/containerresource"
+)
+
+type fakeMetricsClient struct {
+	getContainerResourceMetricReactor func(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error)
+}
+
+func (f *fakeMetricsClient) GetContainerResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return f.getContainerResourceMetricReactor(resource, namespace, selector, container)
+}
+
+func podLister(pods ...*v1.Pod) corelisters.PodLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range pods {
+		indexer.Add(pod)
+	}
+	return corelisters.NewPodLister(indexer)
+}
+
+func readyPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "target",
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceCPU: resource.MustParse("100m"),
+						},
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase:     v1.PodRunning,
+			StartTime: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestGather_GetMetric(t *testing.T) {
+	equateErrorMessage := cmp.Comparer(func(x, y error) bool {
+		if x == nil || y == nil {
+			return x == nil && y == nil
+		}
+		return x.Error() == y.Error()
+	})
+
+	t.Run("success", func(t *testing.T) {
+		gather := &containerresource.Gather{
+			MetricsClient: &fakeMetricsClient{
+				getContainerResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+					return metricsclient.PodMetricsInfo{
+						"pod-1": metricsclient.PodMetric{Value: 50},
+					}, time.Unix(0, 0), nil
+				},
+			},
+			PodLister: podLister(readyPod("pod-1")),
+		}
+
+		result, err := gather.GetMetric(v1.ResourceCPU, "test-namespace", labels.Everything(), "target")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &containerresource.Metric{
+			PodMetricsInfo: metricsclient.PodMetricsInfo{
+				"pod-1": metricsclient.PodMetric{Value: 50},
+			},
+			Requests:      map[string]int64{"pod-1": 100},
+			ReadyPodCount: 1,
+			IgnoredPods:   sets.String{},
+			MissingPods:   sets.String{},
+			TotalPods:     1,
+			Timestamp:     time.Unix(0, 0),
+		}
+		if !cmp.Equal(expected, result) {
+			t.Errorf("metric mismatch (-want +got):\n%s", cmp.Diff(expected, result))
+		}
+	})
+
+	t.Run("fail to get metrics", func(t *testing.T) {
+		gather := &containerresource.Gather{
+			MetricsClient: &fakeMetricsClient{
+				getContainerResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+					return nil, time.Time{}, errors.New("fail to get metrics")
+				},
+			},
+			PodLister: podLister(readyPod("pod-1")),
+		}
+
+		_, err := gather.GetMetric(v1.ResourceCPU, "test-namespace", labels.Everything(), "target")
+		expectedErr := errors.New("unable to get metrics for resource cpu container target: fail to get metrics")
+		if !cmp.Equal(&err, &expectedErr, equateErrorMessage) {
+			t.Errorf("error mismatch (-want +got):\n%s", cmp.Diff(expectedErr, err, equateErrorMessage))
+		}
+	})
+
+	t.Run("missing request for container", func(t *testing.T) {
+		podWithoutRequest := readyPod("pod-1")
+		podWithoutRequest.Spec.Containers[0].Resources.Requests = nil
+
+		gather := &containerresource.Gather{
+			MetricsClient: &fakeMetricsClient{
+				getContainerResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+					return metricsclient.PodMetricsInfo{
+						"pod-1": metricsclient.PodMetric{Value: 50},
+					}, time.Unix(0, 0), nil
+				},
+			},
+			PodLister: podLister(podWithoutRequest),
+		}
+
+		_, err := gather.GetMetric(v1.ResourceCPU, "test-namespace", labels.Everything(), "target")
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+}
+
+func TestGather_GetRawMetric(t *testing.T) {
+	t.Run("success, requests are not calculated", func(t *testing.T) {
+		gather := &containerresource.Gather{
+			MetricsClient: &fakeMetricsClient{
+				getContainerResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+					return metricsclient.PodMetricsInfo{
+						"pod-1": metricsclient.PodMetric{Value: 50},
+					}, time.Unix(0, 0), nil
+				},
+			},
+			PodLister: podLister(readyPod("pod-1")),
+		}
+
+		result, err := gather.GetRawMetric(v1.ResourceCPU, "test-namespace", labels.Everything(), "target")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &containerresource.Metric{
+			PodMetricsInfo: metricsclient.PodMetricsInfo{
+				"pod-1": metricsclient.PodMetric{Value: 50},
+			},
+			ReadyPodCount: 1,
+			IgnoredPods:   sets.String{},
+			MissingPods:   sets.String{},
+			TotalPods:     1,
+			Timestamp:     time.Unix(0, 0),
+		}
+		if !cmp.Equal(expected, result) {
+			t.Errorf("metric mismatch (-want +got):\n%s", cmp.Diff(expected, result))
+		}
+	})
+
+	t.Run("no pods returned by selector", func(t *testing.T) {
+		gather := &containerresource.Gather{
+			MetricsClient: &fakeMetricsClient{
+				getContainerResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+					return metricsclient.PodMetricsInfo{}, time.Unix(0, 0), nil
+				},
+			},
+			PodLister: podLister(),
+		}
+
+		_, err := gather.GetRawMetric(v1.ResourceCPU, "test-namespace", labels.Everything(), "target")
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+}
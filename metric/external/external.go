@@ -0,0 +1,125 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+Modifications Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+
+Modified to split up evaluations and metric gathering to work with the
+Custom Pod Autoscaler framework.
+Original source:
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/horizontal.go
+https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/podautoscaler/replica_calculator.go
+*/
+
+package external
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+	externalmetricsclient "k8s.io/metrics/pkg/client/external_metrics"
+)
+
+// Gatherer (External) allows retrieval of external metrics, sourced from outside the cluster through the
+// external.metrics.k8s.io aggregated API (for example SQS queue depth, or a Prometheus adapter metric).
+type Gatherer interface {
+	GetMetric(metricName string, namespace string, metricSelector labels.Selector, podSelector labels.Selector) (*Metric, error)
+}
+
+// Metric (External) is a metric describing something happening outside the cluster, not tied to any Kubernetes
+// object (for example queue length in a cloud messaging service, or requests per second from a load balancer
+// running outside the cluster).
+type Metric struct {
+	Utilization   int64
+	ReadyPodCount *int64
+	Timestamp     time.Time
+}
+
+// Gather (External) provides functionality for retrieving metrics for external metric specs, querying the
+// external.metrics.k8s.io API directly through the external metrics client, rather than through a MetricsClient
+// adapter.
+type Gather struct {
+	Client    externalmetricsclient.ExternalMetricsClient
+	PodLister corelisters.PodLister
+}
+
+// NewGatherer builds a Gatherer backed directly by externalMetricsClient, the client the wider Kubernetes
+// ecosystem already expects to plug in (see kube-controller-manager's startHPAControllerWithRESTClient), rather
+// than requiring callers to shim their own Gatherer implementation.
+func NewGatherer(externalMetricsClient externalmetricsclient.ExternalMetricsClient, podLister corelisters.PodLister) Gatherer {
+	return &Gather{
+		Client:    externalMetricsClient,
+		PodLister: podLister,
+	}
+}
+
+// GetMetric retrieves an external metric, summing every value returned for metricName matched by metricSelector.
+// If podSelector is non-nil, the number of ready pods it matches is also returned, for use when evaluating a
+// Value (rather than an AverageValue) target.
+func (g *Gather) GetMetric(metricName string, namespace string, metricSelector labels.Selector, podSelector labels.Selector) (*Metric, error) {
+	metrics, err := g.Client.NamespacedMetrics(namespace).List(metricName, metricSelector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get external metric %s/%s/%+v: %v", namespace, metricName, metricSelector, err)
+	}
+
+	if len(metrics.Items) == 0 {
+		return nil, fmt.Errorf("no external metrics returned for %s/%s/%+v", namespace, metricName, metricSelector)
+	}
+
+	timestamp := metrics.Items[0].Timestamp.Time
+	utilization := int64(0)
+	for _, value := range metrics.Items {
+		utilization += value.Value.MilliValue()
+	}
+
+	if podSelector == nil {
+		return &Metric{
+			Utilization: utilization,
+			Timestamp:   timestamp,
+		}, nil
+	}
+
+	readyPodCount, err := g.getReadyPodCount(namespace, podSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metric{
+		Utilization:   utilization,
+		ReadyPodCount: &readyPodCount,
+		Timestamp:     timestamp,
+	}, nil
+}
+
+func (g *Gather) getReadyPodCount(namespace string, selector labels.Selector) (int64, error) {
+	pods, err := g.PodLister.Pods(namespace).List(selector)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get pods while calculating ready pod count: %v", err)
+	}
+
+	readyPodCount := int64(0)
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodRunning && podutil.IsPodReady(pod) {
+			readyPodCount++
+		}
+	}
+	return readyPodCount, nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	externalmetricsapi "k8s.io/metrics/pkg/apis/external_metrics"
+	externalmetricsclient "k8s.io/metrics/pkg/client/external_metrics"
+
+	v1 "k8s.io/api/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/external"
+)
+
+type fakeMetricsGetter struct {
+	listReactor func(metricName string, metricSelector labels.Selector) (*externalmetricsapi.ExternalMetricValueList, error)
+}
+
+func (f fakeMetricsGetter) List(metricName string, metricSelector labels.Selector) (*externalmetricsapi.ExternalMetricValueList, error) {
+	return f.listReactor(metricName, metricSelector)
+}
+
+type fakeExternalMetricsClient struct {
+	getter fakeMetricsGetter
+}
+
+func (f fakeExternalMetricsClient) NamespacedMetrics(namespace string) externalmetricsclient.MetricsGetter {
+	return f.getter
+}
+
+func podLister(pods ...*v1.Pod) corelisters.PodLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range pods {
+		indexer.Add(pod)
+	}
+	return corelisters.NewPodLister(indexer)
+}
+
+func readyPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestGather_GetMetric(t *testing.T) {
+	t.Run("success, no pod selector", func(t *testing.T) {
+		gather := &external.Gather{
+			Client: fakeExternalMetricsClient{
+				getter: fakeMetricsGetter{
+					listReactor: func(metricName string, metricSelector labels.Selector) (*externalmetricsapi.ExternalMetricValueList, error) {
+						return &externalmetricsapi.ExternalMetricValueList{
+							Items: []externalmetricsapi.ExternalMetricValue{
+								{Value: *resource.NewQuantity(5, resource.DecimalSI), Timestamp: metav1.Unix(0, 0)},
+								{Value: *resource.NewQuantity(3, resource.DecimalSI), Timestamp: metav1.Unix(0, 0)},
+							},
+						}, nil
+					},
+				},
+			},
+		}
+
+		result, err := gather.GetMetric("queue_length", "test-namespace", labels.Everything(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &external.Metric{
+			Utilization: 8000,
+			Timestamp:   time.Unix(0, 0),
+		}
+		if !cmp.Equal(expected, result) {
+			t.Errorf("metric mismatch (-want +got):\n%s", cmp.Diff(expected, result))
+		}
+	})
+
+	t.Run("success, with pod selector", func(t *testing.T) {
+		gather := &external.Gather{
+			Client: fakeExternalMetricsClient{
+				getter: fakeMetricsGetter{
+					listReactor: func(metricName string, metricSelector labels.Selector) (*externalmetricsapi.ExternalMetricValueList, error) {
+						return &externalmetricsapi.ExternalMetricValueList{
+							Items: []externalmetricsapi.ExternalMetricValue{
+								{Value: *resource.NewQuantity(10, resource.DecimalSI), Timestamp: metav1.Unix(0, 0)},
+							},
+						}, nil
+					},
+				},
+			},
+			PodLister: podLister(readyPod("pod-1"), readyPod("pod-2")),
+		}
+
+		result, err := gather.GetMetric("queue_length", "test-namespace", labels.Everything(), labels.Everything())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		readyPodCount := int64(2)
+		expected := &external.Metric{
+			Utilization:   10000,
+			ReadyPodCount: &readyPodCount,
+			Timestamp:     time.Unix(0, 0),
+		}
+		if !cmp.Equal(expected, result) {
+			t.Errorf("metric mismatch (-want +got):\n%s", cmp.Diff(expected, result))
+		}
+	})
+
+	t.Run("no metrics returned", func(t *testing.T) {
+		gather := &external.Gather{
+			Client: fakeExternalMetricsClient{
+				getter: fakeMetricsGetter{
+					listReactor: func(metricName string, metricSelector labels.Selector) (*externalmetricsapi.ExternalMetricValueList, error) {
+						return &externalmetricsapi.ExternalMetricValueList{}, nil
+					},
+				},
+			},
+		}
+
+		_, err := gather.GetMetric("queue_length", "test-namespace", labels.Everything(), nil)
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+
+	t.Run("API error is propagated", func(t *testing.T) {
+		gather := &external.Gather{
+			Client: fakeExternalMetricsClient{
+				getter: fakeMetricsGetter{
+					listReactor: func(metricName string, metricSelector labels.Selector) (*externalmetricsapi.ExternalMetricValueList, error) {
+						return nil, errors.New("external metrics API unavailable")
+					},
+				},
+			},
+		}
+
+		_, err := gather.GetMetric("queue_length", "test-namespace", labels.Everything(), nil)
+		if err == nil || !strings.Contains(err.Error(), "external metrics API unavailable") {
+			t.Errorf("expected error to wrap %q, got %v", "external metrics API unavailable", err)
+		}
+	})
+}
+
+func TestNewGatherer(t *testing.T) {
+	client := fakeExternalMetricsClient{}
+	lister := podLister()
+
+	gatherer := external.NewGatherer(client, lister)
+
+	if _, ok := gatherer.(*external.Gather); !ok {
+		t.Errorf("expected NewGatherer to return a *external.Gather, got %T", gatherer)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"fmt"
+	"log"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/util/sets"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+)
+
+// FederationStrategy describes how Value typed Object/External metrics gathered from multiple clusters should be
+// combined into a single value, since unlike AverageValue metrics they cannot simply be summed across clusters.
+type FederationStrategy string
+
+const (
+	// FederationStrategySum sums the values gathered from every cluster.
+	FederationStrategySum FederationStrategy = "Sum"
+	// FederationStrategyAverage averages the values gathered from every cluster.
+	FederationStrategyAverage FederationStrategy = "Average"
+	// FederationStrategyMax takes the highest value gathered across every cluster.
+	FederationStrategyMax FederationStrategy = "Max"
+)
+
+// FederatedGatherer wraps a Gatherer per member cluster, gathering metrics from each and merging them into a
+// single CombinedMetric per spec, so that a logical workload whose pods are spread across several clusters can
+// still be autoscaled as one.
+type FederatedGatherer struct {
+	Gatherers           map[string]*Gatherer
+	ValueMetricStrategy FederationStrategy
+}
+
+// GetMetrics gathers metrics for the provided specs from every member cluster, merging each cluster's result into
+// a single CombinedMetric per spec. A cluster that fails to gather a metric is logged and treated as contributing
+// no pods for that metric, rather than failing the whole gather, matching how Gatherer.GetMetrics tolerates
+// individual invalid specs.
+func (f *FederatedGatherer) GetMetrics(scaleTargetRef autoscaling.CrossVersionObjectReference, scaleObjs map[string]*autoscalingv1.Scale, specs []autoscaling.MetricSpec, namespace string) ([]*CombinedMetric, error) {
+	perCluster := make(map[string][]*CombinedMetric, len(f.Gatherers))
+	for cluster, gatherer := range f.Gatherers {
+		scaleObj, ok := scaleObjs[cluster]
+		if !ok {
+			log.Printf("no scale subresource provided for cluster %q, skipping", cluster)
+			continue
+		}
+		result, err := gatherer.GetMetricsWithStatus(scaleTargetRef, scaleObj, specs, namespace)
+		if err != nil {
+			log.Printf("unable to gather metrics from cluster %q: %v", cluster, err)
+			continue
+		}
+		succeeded := 0
+		for i, metricErr := range result.PerMetricErrors {
+			if metricErr != nil {
+				log.Printf("unable to gather metric %d from cluster %q: %v", i, cluster, metricErr)
+			} else {
+				succeeded++
+			}
+		}
+		if succeeded == 0 {
+			continue
+		}
+		perCluster[cluster] = result.Metrics
+	}
+
+	if len(perCluster) == 0 {
+		return nil, fmt.Errorf("unable to gather metrics from any of the %d member clusters", len(f.Gatherers))
+	}
+
+	merged := make([]*CombinedMetric, len(specs))
+	for i, spec := range specs {
+		var toMerge []*CombinedMetric
+		for cluster, clusterMetrics := range perCluster {
+			if i >= len(clusterMetrics) || clusterMetrics[i] == nil {
+				continue
+			}
+			toMerge = append(toMerge, namespaceCombinedMetric(clusterMetrics[i], cluster))
+		}
+		if len(toMerge) == 0 {
+			continue
+		}
+		merged[i] = f.mergeCombinedMetrics(spec, toMerge)
+	}
+
+	return merged, nil
+}
+
+// namespaceCombinedMetric prefixes pod metric keys with the cluster they were gathered from, so identically named
+// pods in different clusters don't collide when their PodMetricsInfo maps are merged.
+func namespaceCombinedMetric(metric *CombinedMetric, cluster string) *CombinedMetric {
+	if metric.Resource != nil {
+		metric.Resource.PodMetricsInfo = namespacePodMetricsInfo(metric.Resource.PodMetricsInfo, cluster)
+		metric.Resource.IgnoredPods = namespacePodNames(metric.Resource.IgnoredPods, cluster)
+		metric.Resource.MissingPods = namespacePodNames(metric.Resource.MissingPods, cluster)
+	}
+	if metric.Pods != nil {
+		metric.Pods.PodMetricsInfo = namespacePodMetricsInfo(metric.Pods.PodMetricsInfo, cluster)
+		metric.Pods.IgnoredPods = namespacePodNames(metric.Pods.IgnoredPods, cluster)
+		metric.Pods.MissingPods = namespacePodNames(metric.Pods.MissingPods, cluster)
+	}
+	return metric
+}
+
+func namespacePodMetricsInfo(info metricsclient.PodMetricsInfo, cluster string) metricsclient.PodMetricsInfo {
+	namespaced := make(metricsclient.PodMetricsInfo, len(info))
+	for podName, podMetric := range info {
+		namespaced[fmt.Sprintf("%s/%s", cluster, podName)] = podMetric
+	}
+	return namespaced
+}
+
+func namespacePodNames(pods sets.String, cluster string) sets.String {
+	namespaced := sets.NewString()
+	for _, podName := range pods.UnsortedList() {
+		namespaced.Insert(fmt.Sprintf("%s/%s", cluster, podName))
+	}
+	return namespaced
+}
+
+// mergeCombinedMetrics merges the per-cluster CombinedMetrics gathered for a single spec into one. CurrentReplicas
+// is summed across clusters, since it represents the total size of the federated scale target.
+func (f *FederatedGatherer) mergeCombinedMetrics(spec autoscaling.MetricSpec, toMerge []*CombinedMetric) *CombinedMetric {
+	merged := &CombinedMetric{Spec: spec}
+	for _, metric := range toMerge {
+		merged.CurrentReplicas += metric.CurrentReplicas
+	}
+
+	switch spec.Type {
+	case autoscaling.ResourceMetricSourceType:
+		resources := make([]*ResourceMetric, 0, len(toMerge))
+		for _, metric := range toMerge {
+			if metric.Resource != nil {
+				resources = append(resources, metric.Resource)
+			}
+		}
+		merged.Resource = mergePlainMetrics(resources)
+	case autoscaling.PodsMetricSourceType:
+		pods := make([]*PodsMetric, 0, len(toMerge))
+		for _, metric := range toMerge {
+			if metric.Pods != nil {
+				pods = append(pods, metric.Pods)
+			}
+		}
+		merged.Pods = mergePodsMetrics(pods)
+	case autoscaling.ObjectMetricSourceType:
+		merged.Object = f.mergeObjectOrExternalMetrics(spec.Object.Target.Type, toMerge, func(m *CombinedMetric) *ObjectMetric { return m.Object })
+	case autoscaling.ExternalMetricSourceType:
+		merged.External = f.mergeExternalMetrics(spec.External.Target.Type, toMerge)
+	}
+
+	return merged
+}
+
+func mergePlainMetrics(resources []*ResourceMetric) *ResourceMetric {
+	if len(resources) == 0 {
+		return nil
+	}
+	merged := &ResourceMetric{
+		PodMetricsInfo: metricsclient.PodMetricsInfo{},
+		Requests:       map[string]int64{},
+		IgnoredPods:    sets.NewString(),
+		MissingPods:    sets.NewString(),
+	}
+	for _, resource := range resources {
+		for podName, podMetric := range resource.PodMetricsInfo {
+			merged.PodMetricsInfo[podName] = podMetric
+		}
+		for podName, request := range resource.Requests {
+			merged.Requests[podName] = request
+		}
+		merged.IgnoredPods = merged.IgnoredPods.Union(resource.IgnoredPods)
+		merged.MissingPods = merged.MissingPods.Union(resource.MissingPods)
+		merged.ReadyPodCount += resource.ReadyPodCount
+		merged.TotalPods += resource.TotalPods
+		if merged.Timestamp.IsZero() || resource.Timestamp.Before(merged.Timestamp) {
+			merged.Timestamp = resource.Timestamp
+		}
+	}
+	return merged
+}
+
+func mergePodsMetrics(pods []*PodsMetric) *PodsMetric {
+	if len(pods) == 0 {
+		return nil
+	}
+	merged := &PodsMetric{
+		PodMetricsInfo: metricsclient.PodMetricsInfo{},
+		IgnoredPods:    sets.NewString(),
+		MissingPods:    sets.NewString(),
+	}
+	for _, pod := range pods {
+		for podName, podMetric := range pod.PodMetricsInfo {
+			merged.PodMetricsInfo[podName] = podMetric
+		}
+		merged.IgnoredPods = merged.IgnoredPods.Union(pod.IgnoredPods)
+		merged.MissingPods = merged.MissingPods.Union(pod.MissingPods)
+		merged.ReadyPodCount += pod.ReadyPodCount
+		merged.TotalPods += pod.TotalPods
+		if merged.Timestamp.IsZero() || pod.Timestamp.Before(merged.Timestamp) {
+			merged.Timestamp = pod.Timestamp
+		}
+	}
+	return merged
+}
+
+// mergeObjectOrExternalMetrics merges Object metrics: AverageValue targets sum Utilization/ReadyPodCount across
+// clusters, while Value targets are combined using the configured FederationStrategy.
+func (f *FederatedGatherer) mergeObjectOrExternalMetrics(targetType autoscaling.MetricTargetType, toMerge []*CombinedMetric, get func(*CombinedMetric) *ObjectMetric) *ObjectMetric {
+	var values []int64
+	merged := &ObjectMetric{}
+	readyPodCount := int64(0)
+	for _, metric := range toMerge {
+		object := get(metric)
+		if object == nil {
+			continue
+		}
+		values = append(values, object.Utilization)
+		if object.ReadyPodCount != nil {
+			readyPodCount += *object.ReadyPodCount
+		}
+		if merged.Timestamp.IsZero() || object.Timestamp.Before(merged.Timestamp) {
+			merged.Timestamp = object.Timestamp
+		}
+	}
+	if targetType == autoscaling.AverageValueMetricType {
+		merged.ReadyPodCount = &readyPodCount
+		merged.Utilization = sum(values)
+	} else {
+		merged.Utilization = f.combine(values)
+	}
+	return merged
+}
+
+func (f *FederatedGatherer) mergeExternalMetrics(targetType autoscaling.MetricTargetType, toMerge []*CombinedMetric) *ExternalMetric {
+	var values []int64
+	merged := &ExternalMetric{}
+	readyPodCount := int64(0)
+	for _, metric := range toMerge {
+		if metric.External == nil {
+			continue
+		}
+		values = append(values, metric.External.Utilization)
+		if metric.External.ReadyPodCount != nil {
+			readyPodCount += *metric.External.ReadyPodCount
+		}
+		if merged.Timestamp.IsZero() || metric.External.Timestamp.Before(merged.Timestamp) {
+			merged.Timestamp = metric.External.Timestamp
+		}
+	}
+	if targetType == autoscaling.AverageValueMetricType {
+		merged.ReadyPodCount = &readyPodCount
+		merged.Utilization = sum(values)
+	} else {
+		merged.Utilization = f.combine(values)
+	}
+	return merged
+}
+
+// combine merges values gathered from multiple clusters for a Value-targeted metric (a cluster-wide total, such
+// as queue depth) using f.ValueMetricStrategy. AverageValue-targeted metrics (a per-pod rate) must never go
+// through here: they represent the same quantity in every cluster and are always summed via sum, regardless of
+// ValueMetricStrategy - see mergeObjectOrExternalMetrics/mergeExternalMetrics.
+func (f *FederatedGatherer) combine(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	strategy := f.ValueMetricStrategy
+	if strategy == "" {
+		strategy = FederationStrategySum
+	}
+
+	switch strategy {
+	case FederationStrategyMax:
+		max := values[0]
+		for _, value := range values[1:] {
+			if value > max {
+				max = value
+			}
+		}
+		return max
+	case FederationStrategyAverage:
+		return sum(values) / int64(len(values))
+	default:
+		return sum(values)
+	}
+}
+
+// sum adds every value together, the strategy-independent combination AverageValue-targeted metrics always use.
+func sum(values []int64) int64 {
+	total := int64(0)
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric_test
+
+import (
+	"testing"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+)
+
+// externalMetricSpec builds an External metric spec targeted by targetType. FederatedGatherer.mergeCombinedMetrics
+// decides how to combine per-cluster values from the target's Type, while Gatherer picks its per-pod or whole-metric
+// External gather by whether AverageValue or AverageUtilization is set, so the matching one is set here too.
+func externalMetricSpec(targetType autoscaling.MetricTargetType) autoscaling.MetricSpec {
+	target := autoscaling.MetricTarget{Type: targetType}
+	if targetType == autoscaling.AverageValueMetricType {
+		target.AverageValue = resource.NewQuantity(1, resource.DecimalSI)
+	} else {
+		utilization := int32(1)
+		target.AverageUtilization = &utilization
+	}
+	return autoscaling.MetricSpec{
+		Type: autoscaling.ExternalMetricSourceType,
+		External: &autoscaling.ExternalMetricSource{
+			Metric: autoscaling.MetricIdentifier{Name: "queue-length"},
+			Target: target,
+		},
+	}
+}
+
+func fakeGathererReturning(utilization int64) *metric.Gatherer {
+	return &metric.Gatherer{
+		MetricsClient: &fakeMetricsClient{
+			getExternalMetricReactor: func(metricName string, namespace string, selector labels.Selector) ([]int64, time.Time, error) {
+				return []int64{utilization}, time.Unix(0, 0), nil
+			},
+		},
+		PodLister: statusPodLister(statusReadyPod("pod-1", "100m")),
+	}
+}
+
+func TestFederatedGatherer_GetMetrics_externalFederationStrategy(t *testing.T) {
+	tests := []struct {
+		description string
+		strategy    metric.FederationStrategy
+		targetType  autoscaling.MetricTargetType
+		values      map[string]int64
+		expected    int64
+	}{
+		{
+			description: "Value target, Sum strategy sums across clusters",
+			strategy:    metric.FederationStrategySum,
+			targetType:  autoscaling.ValueMetricType,
+			values:      map[string]int64{"a": 10, "b": 20},
+			expected:    30,
+		},
+		{
+			description: "Value target, Average strategy averages across clusters",
+			strategy:    metric.FederationStrategyAverage,
+			targetType:  autoscaling.ValueMetricType,
+			values:      map[string]int64{"a": 10, "b": 20},
+			expected:    15,
+		},
+		{
+			description: "Value target, Max strategy takes the highest cluster value",
+			strategy:    metric.FederationStrategyMax,
+			targetType:  autoscaling.ValueMetricType,
+			values:      map[string]int64{"a": 10, "b": 20},
+			expected:    20,
+		},
+		{
+			description: "AverageValue target always sums, regardless of a Max strategy",
+			strategy:    metric.FederationStrategyMax,
+			targetType:  autoscaling.AverageValueMetricType,
+			values:      map[string]int64{"a": 10, "b": 20},
+			expected:    30,
+		},
+		{
+			description: "AverageValue target always sums, regardless of an Average strategy",
+			strategy:    metric.FederationStrategyAverage,
+			targetType:  autoscaling.AverageValueMetricType,
+			values:      map[string]int64{"a": 10, "b": 20},
+			expected:    30,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			gatherers := make(map[string]*metric.Gatherer, len(test.values))
+			scaleObjs := make(map[string]*autoscalingv1.Scale, len(test.values))
+			for cluster, value := range test.values {
+				gatherers[cluster] = fakeGathererReturning(value)
+				scaleObjs[cluster] = &autoscalingv1.Scale{
+					Spec:   autoscalingv1.ScaleSpec{Replicas: 1},
+					Status: autoscalingv1.ScaleStatus{Selector: "app=test"},
+				}
+			}
+
+			federated := &metric.FederatedGatherer{
+				Gatherers:           gatherers,
+				ValueMetricStrategy: test.strategy,
+			}
+
+			specs := []autoscaling.MetricSpec{externalMetricSpec(test.targetType)}
+			scaleTargetRef := autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"}
+
+			metrics, err := federated.GetMetrics(scaleTargetRef, scaleObjs, specs, "test-namespace")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(metrics) != 1 || metrics[0].External == nil {
+				t.Fatalf("expected a single merged External metric, got %+v", metrics)
+			}
+			if metrics[0].External.Utilization != test.expected {
+				t.Errorf("expected merged utilization %d, got %d", test.expected, metrics[0].External.Utilization)
+			}
+		})
+	}
+}
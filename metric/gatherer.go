@@ -33,8 +33,8 @@ import (
 	"fmt"
 	"time"
 
-	appsv1 "k8s.io/api/apps/v1"
-	autoscaling "k8s.io/api/autoscaling/v2beta2"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -42,28 +42,90 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/annotation"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/containerresource"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/external"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/node"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prediction"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
 )
 
 // Gatherer provides functionality for retrieving metrics on supplied metric specs.
 type Gatherer struct {
-	MetricsClient                 metricsclient.MetricsClient
-	PodLister                     corelisters.PodLister
-	CPUInitializationPeriod       time.Duration
+	MetricsClient           MetricsClient
+	PodLister               corelisters.PodLister
+	CPUInitializationPeriod time.Duration
+	// MemoryInitializationPeriod is CPUInitializationPeriod's counterpart for memory ResourceMetrics: while a pod
+	// is within this period of its start time, a not-yet-Ready memory sample is classified into IgnoredPods rather
+	// than contributing to the average, the same way CPUInitializationPeriod avoids counting a pod's CPU usage
+	// before it settles. This avoids scale-outs driven by unready pods whose memory is still warming up (for
+	// example JVM or Go runtime baseline allocations).
+	MemoryInitializationPeriod    time.Duration
 	DelayOfInitialReadinessStatus time.Duration
+	// AnnotationInferer, if set, synthesizes additional MetricSpecs from the scale target's annotations
+	// (see the metric/annotation package), merging them with the specs passed into GetMetrics. Leave nil to
+	// disable annotation-driven metric inference and only use the specs supplied by the caller.
+	AnnotationInferer annotation.Inferer
+	// Prometheus, if set, handles MetricSpecs with Type prometheus.MetricSourceType, a synthetic metric source
+	// carrying a PromQL query to execute directly against Prometheus. Leave nil to reject such specs.
+	Prometheus prometheus.Gatherer
+	// ContainerResource handles ContainerResource metric specs. Leave nil to use the default Gatherer, built from
+	// MetricsClient, PodLister, CPUInitializationPeriod and DelayOfInitialReadinessStatus.
+	ContainerResource containerresource.Gatherer
+	// External, if set, handles External metric specs directly through an external.Gatherer (for example one
+	// built with external.NewGatherer, querying the external.metrics.k8s.io API directly), bypassing MetricsClient
+	// entirely for this metric source type. Leave nil to use MetricsClient's GetExternalMetric instead.
+	External external.Gatherer
+	// Prediction, if set, handles MetricSpecs with Type prediction.MetricSourceType, a synthetic metric source
+	// that forecasts an existing metric forward by PredictionHorizon. Leave nil to reject such specs.
+	// evaluate.Evaluate has no case for this type, so a gathered Prediction metric fails evaluation.
+	Prediction prediction.Gatherer
+	// PredictionHorizon is how far ahead Prediction should forecast. Only meaningful when Prediction is set.
+	PredictionHorizon time.Duration
+	// Node, if set, handles MetricSpecs with Type node.MetricSourceType, a synthetic metric source that measures
+	// aggregate node resource pressure rather than the scale target's own pods. Leave nil to reject such specs.
+	// evaluate.Evaluate has no case for this type, so a gathered Node metric fails evaluation.
+	Node node.Gatherer
+}
+
+// containerResourceGatherer returns c.ContainerResource if set, otherwise a default built from c's own fields.
+func (c *Gatherer) containerResourceGatherer() containerresource.Gatherer {
+	if c.ContainerResource != nil {
+		return c.ContainerResource
+	}
+	return &containerresource.Gather{
+		MetricsClient:                 c.MetricsClient,
+		PodLister:                     c.PodLister,
+		CPUInitializationPeriod:       c.CPUInitializationPeriod,
+		MemoryInitializationPeriod:    c.MemoryInitializationPeriod,
+		DelayOfInitialReadinessStatus: c.DelayOfInitialReadinessStatus,
+	}
 }
 
 // GetMetrics processes each MetricSpec provided, calculating metric values for each and combining them into a slice before returning them.
+// The scale target is described by scaleTargetRef, with its current state resolved through the scale subresource
+// (scaleObj), rather than requiring the caller to pass a concrete type such as a Deployment. This allows any
+// resource that exposes a /scale subresource (Deployments, StatefulSets, ReplicaSets, Argo Rollouts, or any CRD
+// backed by a scale.ScalesGetter) to be gathered for.
 // Error will only be returned if all metrics are invalid, otherwise it will return the valid metrics.
-func (c *Gatherer) GetMetrics(deployment *appsv1.Deployment, specs []autoscaling.MetricSpec, namespace string) ([]*CombinedMetric, error) {
+func (c *Gatherer) GetMetrics(scaleTargetRef autoscaling.CrossVersionObjectReference, scaleObj *autoscalingv1.Scale, specs []autoscaling.MetricSpec, namespace string) ([]*CombinedMetric, error) {
 	var combinedMetrics []*CombinedMetric
 	var invalidMetricError error
 	invalidMetricsCount := 0
-	currentReplicas := int32(0)
-	if deployment.Spec.Replicas != nil {
-		currentReplicas = *deployment.Spec.Replicas
+	currentReplicas := scaleObj.Spec.Replicas
+
+	if c.AnnotationInferer != nil {
+		specs = append(specs, c.AnnotationInferer.Infer(scaleObj, scaleObj.Annotations)...)
 	}
+
+	selector, err := labels.Parse(scaleObj.Status.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse scale subresource selector for %s %s/%s: %v", scaleTargetRef.Kind, namespace, scaleTargetRef.Name, err)
+	}
+
 	for _, spec := range specs {
-		metric, err := c.getMetric(currentReplicas, spec, namespace, labels.Set(deployment.Labels).AsSelector())
+		metric, err := c.getMetric(currentReplicas, spec, namespace, selector)
 		if err != nil {
 			if invalidMetricsCount <= 0 {
 				invalidMetricError = err
@@ -82,6 +144,93 @@ func (c *Gatherer) GetMetrics(deployment *appsv1.Deployment, specs []autoscaling
 	return combinedMetrics, nil
 }
 
+// GetMetricsWithStatus gathers metrics the same way GetMetrics does, but never fails an individual metric out of
+// the result: every spec is attempted, and the outcome (metric or error) is recorded positionally in the returned
+// GatherResult, alongside a best-effort set of HorizontalPodAutoscalerConditions summarising the attempt. Unlike
+// GetMetrics, a GatherResult is always returned (even if every metric failed); callers that want the "error unless
+// at least one metric succeeded" behaviour of GetMetrics should inspect the ScalingActive condition instead.
+func (c *Gatherer) GetMetricsWithStatus(scaleTargetRef autoscaling.CrossVersionObjectReference, scaleObj *autoscalingv1.Scale, specs []autoscaling.MetricSpec, namespace string) (*GatherResult, error) {
+	currentReplicas := scaleObj.Spec.Replicas
+
+	if c.AnnotationInferer != nil {
+		specs = append(specs, c.AnnotationInferer.Infer(scaleObj, scaleObj.Annotations)...)
+	}
+
+	selector, err := labels.Parse(scaleObj.Status.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse scale subresource selector for %s %s/%s: %v", scaleTargetRef.Kind, namespace, scaleTargetRef.Name, err)
+	}
+
+	metrics := make([]*CombinedMetric, len(specs))
+	perMetricErrors := make([]error, len(specs))
+	successCount := 0
+	var firstErr error
+
+	for i, spec := range specs {
+		combinedMetric, err := c.getMetric(currentReplicas, spec, namespace, selector)
+		if err != nil {
+			perMetricErrors[i] = err
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		combinedMetric.Status = buildMetricStatus(spec, combinedMetric)
+		metrics[i] = combinedMetric
+		successCount++
+	}
+
+	return &GatherResult{
+		Metrics:         metrics,
+		PerMetricErrors: perMetricErrors,
+		Conditions:      gatherConditions(len(specs), successCount, firstErr),
+	}, nil
+}
+
+// gatherConditions builds the HorizontalPodAutoscalerConditions summarising a gathering attempt covering
+// specCount specs, of which successCount succeeded. firstErr, if any, is the first per-metric error encountered.
+// ScalingLimited isn't set here: whether scaling is being limited by the HPA's min/max replica bounds is a
+// decision for the evaluator, not the gatherer, so it's reported as Unknown.
+func gatherConditions(specCount, successCount int, firstErr error) []autoscaling.HorizontalPodAutoscalerCondition {
+	now := metav1.Now()
+
+	ableToScale := autoscaling.HorizontalPodAutoscalerCondition{
+		Type:               autoscaling.AbleToScale,
+		Status:             v1.ConditionTrue,
+		LastTransitionTime: now,
+		Reason:             "SucceededGetScale",
+		Message:            "the HPA controller was able to get the target's current scale",
+	}
+
+	scalingActive := autoscaling.HorizontalPodAutoscalerCondition{
+		Type:               autoscaling.ScalingActive,
+		LastTransitionTime: now,
+	}
+	if successCount > 0 {
+		scalingActive.Status = v1.ConditionTrue
+		scalingActive.Reason = "ValidMetricFound"
+		scalingActive.Message = fmt.Sprintf("the HPA was able to successfully calculate a replica count from %d of %d metric(s)", successCount, specCount)
+	} else {
+		scalingActive.Status = v1.ConditionFalse
+		scalingActive.Reason = "FailedGetMetrics"
+		message := "the HPA was unable to compute the replica count from any metric"
+		if firstErr != nil {
+			message = fmt.Sprintf("%s: %v", message, firstErr)
+		}
+		scalingActive.Message = message
+	}
+
+	scalingLimited := autoscaling.HorizontalPodAutoscalerCondition{
+		Type:               autoscaling.ScalingLimited,
+		Status:             v1.ConditionUnknown,
+		LastTransitionTime: now,
+		Reason:             "NotEvaluated",
+		Message:            "whether scaling is limited by the HPA's min/max replica bounds is determined by the evaluator, not the gatherer",
+	}
+
+	return []autoscaling.HorizontalPodAutoscalerCondition{ableToScale, scalingActive, scalingLimited}
+}
+
 func (c *Gatherer) getMetric(currentReplicas int32, spec autoscaling.MetricSpec, namespace string, selector labels.Selector) (*CombinedMetric, error) {
 	switch spec.Type {
 	case autoscaling.ObjectMetricSourceType:
@@ -158,7 +307,72 @@ func (c *Gatherer) getMetric(currentReplicas int32, spec autoscaling.MetricSpec,
 
 		return nil, fmt.Errorf("invalid resource metric source: neither a utilization target nor a value target was set")
 
+	case autoscaling.ContainerResourceMetricSourceType:
+		// ContainerResource is dispatched to containerResourceGatherer() rather than handled inline like Resource
+		// above, since it needs to filter each pod down to a single named container before computing utilization;
+		// see the containerresource package for that logic.
+		gatherer := c.containerResourceGatherer()
+
+		if spec.ContainerResource.Target.AverageValue != nil {
+			containerResourceMetric, err := gatherer.GetRawMetric(spec.ContainerResource.Name, namespace, selector, spec.ContainerResource.Container)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get container resource metric: %v", err)
+			}
+			return &CombinedMetric{
+				CurrentReplicas:   currentReplicas,
+				Spec:              spec,
+				ContainerResource: containerResourceMetricFromGatherer(containerResourceMetric),
+			}, nil
+		}
+
+		if spec.ContainerResource.Target.AverageUtilization != nil {
+			containerResourceMetric, err := gatherer.GetMetric(spec.ContainerResource.Name, namespace, selector, spec.ContainerResource.Container)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get container resource metric: %v", err)
+			}
+			return &CombinedMetric{
+				CurrentReplicas:   currentReplicas,
+				Spec:              spec,
+				ContainerResource: containerResourceMetricFromGatherer(containerResourceMetric),
+			}, nil
+		}
+
+		return nil, fmt.Errorf("invalid container resource metric source: neither a utilization target nor a value target was set")
+
 	case autoscaling.ExternalMetricSourceType:
+		if c.External != nil {
+			metricSelector, err := metav1.LabelSelectorAsSelector(spec.External.Metric.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get external metric: %v", err)
+			}
+
+			if spec.External.Target.AverageValue != nil {
+				externalMetric, err := c.External.GetMetric(spec.External.Metric.Name, namespace, metricSelector, nil)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get external metric: %v", err)
+				}
+				return &CombinedMetric{
+					CurrentReplicas: currentReplicas,
+					Spec:            spec,
+					External:        externalMetricFromGatherer(externalMetric),
+				}, nil
+			}
+
+			if spec.External.Target.AverageUtilization != nil {
+				externalMetric, err := c.External.GetMetric(spec.External.Metric.Name, namespace, metricSelector, selector)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get external metric: %v", err)
+				}
+				return &CombinedMetric{
+					CurrentReplicas: currentReplicas,
+					Spec:            spec,
+					External:        externalMetricFromGatherer(externalMetric),
+				}, nil
+			}
+
+			return nil, fmt.Errorf("invalid external metric source: neither a value target nor an average value target was set")
+		}
+
 		if spec.External.Target.AverageValue != nil {
 			externalMetric, err := c.getExternalPerPodMetrics(spec.External.Metric.Name, namespace, spec.External.Metric.Selector)
 			if err != nil {
@@ -184,6 +398,56 @@ func (c *Gatherer) getMetric(currentReplicas int32, spec autoscaling.MetricSpec,
 		}
 		return nil, fmt.Errorf("invalid external metric source: neither a value target nor an average value target was set")
 
+	case prometheus.MetricSourceType:
+		if c.Prometheus == nil {
+			return nil, fmt.Errorf("no prometheus gatherer configured to handle metric source type %q", prometheus.MetricSourceType)
+		}
+
+		promMetric, err := c.Prometheus.GetMetric(spec.External.Metric.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get prometheus metric: %v", err)
+		}
+		return &CombinedMetric{
+			CurrentReplicas: currentReplicas,
+			Spec:            spec,
+			Prometheus:      promMetric,
+		}, nil
+
+	case prediction.MetricSourceType:
+		if c.Prediction == nil {
+			return nil, fmt.Errorf("no prediction gatherer configured to handle metric source type %q", prediction.MetricSourceType)
+		}
+
+		predictionMetric, err := c.Prediction.GetMetric(spec.External.Metric.Name, namespace, selector, c.PredictionHorizon)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get prediction metric: %v", err)
+		}
+		return &CombinedMetric{
+			CurrentReplicas: currentReplicas,
+			Spec:            spec,
+			Prediction:      predictionMetric,
+		}, nil
+
+	case node.MetricSourceType:
+		if c.Node == nil {
+			return nil, fmt.Errorf("no node gatherer configured to handle metric source type %q", node.MetricSourceType)
+		}
+
+		nodeSelector, err := metav1.LabelSelectorAsSelector(spec.External.Metric.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node metric: %v", err)
+		}
+
+		nodeMetric, err := c.Node.GetMetric(v1.ResourceName(spec.External.Metric.Name), nodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node metric: %v", err)
+		}
+		return &CombinedMetric{
+			CurrentReplicas: currentReplicas,
+			Spec:            spec,
+			Node:            nodeMetric,
+		}, nil
+
 	default:
 		return nil, fmt.Errorf("unknown metric source type %q", string(spec.Type))
 	}
@@ -208,7 +472,7 @@ func (c *Gatherer) getResourceMetric(resource v1.ResourceName, namespace string,
 	}
 
 	// Remove missing pod metrics
-	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, resource, c.CPUInitializationPeriod, c.DelayOfInitialReadinessStatus)
+	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, resource, c.CPUInitializationPeriod, c.MemoryInitializationPeriod, c.DelayOfInitialReadinessStatus)
 	removeMetricsForPods(metrics, ignoredPods)
 
 	// Calculate requests - limits for pod resources
@@ -247,7 +511,7 @@ func (c *Gatherer) getRawResourceMetric(resource v1.ResourceName, namespace stri
 	}
 
 	// Remove missing pod metrics
-	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, resource, c.CPUInitializationPeriod, c.DelayOfInitialReadinessStatus)
+	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, resource, c.CPUInitializationPeriod, c.MemoryInitializationPeriod, c.DelayOfInitialReadinessStatus)
 	removeMetricsForPods(metrics, ignoredPods)
 
 	return &ResourceMetric{
@@ -260,6 +524,30 @@ func (c *Gatherer) getRawResourceMetric(resource v1.ResourceName, namespace stri
 	}, nil
 }
 
+// containerResourceMetricFromGatherer adapts a containerresource.Metric, returned by the injectable
+// containerresource.Gatherer, to the ResourceMetric shape every other metric source populates on CombinedMetric.
+func containerResourceMetricFromGatherer(metric *containerresource.Metric) *ResourceMetric {
+	return &ResourceMetric{
+		PodMetricsInfo: metric.PodMetricsInfo,
+		Requests:       metric.Requests,
+		ReadyPodCount:  metric.ReadyPodCount,
+		IgnoredPods:    metric.IgnoredPods,
+		MissingPods:    metric.MissingPods,
+		TotalPods:      metric.TotalPods,
+		Timestamp:      metric.Timestamp,
+	}
+}
+
+// externalMetricFromGatherer adapts an external.Metric, returned by the injectable external.Gatherer, to the
+// ExternalMetric shape every other metric source populates on CombinedMetric.
+func externalMetricFromGatherer(metric *external.Metric) *ExternalMetric {
+	return &ExternalMetric{
+		Utilization:   metric.Utilization,
+		ReadyPodCount: metric.ReadyPodCount,
+		Timestamp:     metric.Timestamp,
+	}
+}
+
 func (c *Gatherer) getPodsMetric(metricName string, namespace string, selector labels.Selector, metricSelector labels.Selector) (*PodsMetric, error) {
 	// Get metrics
 	metrics, timestamp, err := c.MetricsClient.GetRawMetric(metricName, namespace, selector, metricSelector)
@@ -283,7 +571,7 @@ func (c *Gatherer) getPodsMetric(metricName string, namespace string, selector l
 	}
 
 	// Remove missing pod metrics
-	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, v1.ResourceName(""), c.CPUInitializationPeriod, c.DelayOfInitialReadinessStatus)
+	readyPodCount, ignoredPods, missingPods := groupPods(podList, metrics, v1.ResourceName(""), c.CPUInitializationPeriod, c.MemoryInitializationPeriod, c.DelayOfInitialReadinessStatus)
 	removeMetricsForPods(metrics, ignoredPods)
 
 	return &PodsMetric{
@@ -406,9 +694,21 @@ func (c *Gatherer) getReadyPodsCount(namespace string, selector labels.Selector)
 	return readyPodCount, nil
 }
 
-func groupPods(pods []*v1.Pod, metrics metricsclient.PodMetricsInfo, resource v1.ResourceName, cpuInitializationPeriod, delayOfInitialReadinessStatus time.Duration) (readyPodCount int, ignoredPods sets.String, missingPods sets.String) {
+func groupPods(pods []*v1.Pod, metrics metricsclient.PodMetricsInfo, resource v1.ResourceName, cpuInitializationPeriod, memoryInitializationPeriod, delayOfInitialReadinessStatus time.Duration) (readyPodCount int, ignoredPods sets.String, missingPods sets.String) {
 	missingPods = sets.NewString()
 	ignoredPods = sets.NewString()
+
+	// Memory gets the same not-yet-Ready skipping treatment as CPU, since a pod's memory usage can still be
+	// climbing from its runtime's baseline allocations shortly after starting, which would otherwise be counted
+	// against the target and drive an unwanted scale-out.
+	var initializationPeriod time.Duration
+	switch resource {
+	case v1.ResourceCPU:
+		initializationPeriod = cpuInitializationPeriod
+	case v1.ResourceMemory:
+		initializationPeriod = memoryInitializationPeriod
+	}
+
 	for _, pod := range pods {
 		if pod.DeletionTimestamp != nil || pod.Status.Phase == v1.PodFailed {
 			continue
@@ -418,14 +718,14 @@ func groupPods(pods []*v1.Pod, metrics metricsclient.PodMetricsInfo, resource v1
 			missingPods.Insert(pod.Name)
 			continue
 		}
-		if resource == v1.ResourceCPU {
+		if resource == v1.ResourceCPU || resource == v1.ResourceMemory {
 			var ignorePod bool
 			_, condition := podutil.GetPodCondition(&pod.Status, v1.PodReady)
 			if condition == nil || pod.Status.StartTime == nil {
 				ignorePod = true
 			} else {
 				// Pod still within possible initialisation period.
-				if pod.Status.StartTime.Add(cpuInitializationPeriod).After(time.Now()) {
+				if pod.Status.StartTime.Add(initializationPeriod).After(time.Now()) {
 					// Ignore sample if pod is unready or one window of metric wasn't collected since last state transition.
 					ignorePod = condition.Status == v1.ConditionFalse || metric.Timestamp.Before(condition.LastTransitionTime.Time.Add(metric.Window))
 				} else {
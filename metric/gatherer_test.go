@@ -24,13 +24,14 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/fake"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/containerresource"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/external"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/object"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/pods"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/podutil"
 	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/resource"
 	appsv1 "k8s.io/api/apps/v1"
-	autoscaling "k8s.io/api/autoscaling/v2beta2"
+	autoscaling "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
 	k8sresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -57,16 +58,17 @@ func TestGetMetrics(t *testing.T) {
 	})
 
 	var tests = []struct {
-		description string
-		expected    []*metric.Metric
-		expectedErr error
-		resource    resource.Gatherer
-		object      object.Gatherer
-		pods        pods.Gatherer
-		external    external.Gatherer
-		deployment  metav1.Object
-		specs       []autoscaling.MetricSpec
-		namespace   string
+		description       string
+		expected          []*metric.Metric
+		expectedErr       error
+		resource          resource.Gatherer
+		object            object.Gatherer
+		pods              pods.Gatherer
+		external          external.Gatherer
+		containerResource containerresource.Gatherer
+		deployment        metav1.Object
+		specs             []autoscaling.MetricSpec
+		namespace         string
 	}{
 		{
 			"Single invalid resource type",
@@ -76,6 +78,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.DaemonSet{},
 			[]autoscaling.MetricSpec{
 				{
@@ -92,6 +95,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -112,6 +116,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -143,6 +148,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -172,6 +178,7 @@ func TestGetMetrics(t *testing.T) {
 			},
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -220,6 +227,7 @@ func TestGetMetrics(t *testing.T) {
 			},
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -249,6 +257,7 @@ func TestGetMetrics(t *testing.T) {
 			},
 			nil,
 			nil,
+			nil,
 			&appsv1.ReplicaSet{
 				Spec: appsv1.ReplicaSetSpec{
 					Replicas: int32Ptr(1),
@@ -297,6 +306,7 @@ func TestGetMetrics(t *testing.T) {
 			},
 			nil,
 			nil,
+			nil,
 			&appsv1.StatefulSet{
 				Spec: appsv1.StatefulSetSpec{
 					Replicas: int32Ptr(3),
@@ -322,6 +332,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -357,6 +368,7 @@ func TestGetMetrics(t *testing.T) {
 				},
 			},
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -413,6 +425,7 @@ func TestGetMetrics(t *testing.T) {
 				},
 			},
 			nil,
+			nil,
 			&v1.ReplicationController{
 				Spec: v1.ReplicationControllerSpec{
 					Replicas: int32Ptr(8),
@@ -438,6 +451,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(3),
@@ -468,6 +482,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -538,6 +553,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(9),
@@ -568,6 +584,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(1),
@@ -632,6 +649,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(9),
@@ -650,6 +668,105 @@ func TestGetMetrics(t *testing.T) {
 			},
 			"test-namespace",
 		},
+		{
+			"Single container resource metric, average utilisation, fail to get metric",
+			nil,
+			errors.New(`invalid metrics (1 invalid out of 1), first error is: failed to get container resource metric: fail to get container resource metric`),
+			nil,
+			nil,
+			nil,
+			nil,
+			&fake.ContainerResourceGatherer{
+				GetMetricReactor: func(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (*containerresource.Metric, error) {
+					return nil, errors.New("fail to get container resource metric")
+				},
+			},
+			&appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Replicas: int32Ptr(1),
+				},
+			},
+			[]autoscaling.MetricSpec{
+				{
+					Type: autoscaling.ContainerResourceMetricSourceType,
+					ContainerResource: &autoscaling.ContainerResourceMetricSource{
+						Name:      "test-resource",
+						Container: "test-container",
+						Target: autoscaling.MetricTarget{
+							AverageUtilization: int32Ptr(5),
+						},
+					},
+				},
+			},
+			"test-namespace",
+		},
+		{
+			"Single container resource metric, average utilisation, success",
+			[]*metric.Metric{
+				{
+					CurrentReplicas: 6,
+					Spec: autoscaling.MetricSpec{
+						Type: autoscaling.ContainerResourceMetricSourceType,
+						ContainerResource: &autoscaling.ContainerResourceMetricSource{
+							Name:      "test-resource",
+							Container: "test-container",
+							Target: autoscaling.MetricTarget{
+								AverageUtilization: int32Ptr(3),
+							},
+						},
+					},
+					ContainerResource: &containerresource.Metric{
+						PodMetricsInfo: metricsclient.PodMetricsInfo{},
+						ReadyPodCount:  4,
+						TotalPods:      6,
+						MissingPods: sets.String{
+							"missing-pod": {},
+						},
+						IgnoredPods: sets.String{
+							"ignored-pod": {},
+						},
+					},
+				},
+			},
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			&fake.ContainerResourceGatherer{
+				GetMetricReactor: func(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (*containerresource.Metric, error) {
+					return &containerresource.Metric{
+						PodMetricsInfo: metricsclient.PodMetricsInfo{},
+						ReadyPodCount:  4,
+						TotalPods:      6,
+						MissingPods: sets.String{
+							"missing-pod": {},
+						},
+						IgnoredPods: sets.String{
+							"ignored-pod": {},
+						},
+					}, nil
+				},
+			},
+			&appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Replicas: int32Ptr(6),
+				},
+			},
+			[]autoscaling.MetricSpec{
+				{
+					Type: autoscaling.ContainerResourceMetricSourceType,
+					ContainerResource: &autoscaling.ContainerResourceMetricSource{
+						Name:      "test-resource",
+						Container: "test-container",
+						Target: autoscaling.MetricTarget{
+							AverageUtilization: int32Ptr(3),
+						},
+					},
+				},
+			},
+			"test-namespace",
+		},
 		{
 			"Single external metric, invalid target",
 			nil,
@@ -658,6 +775,7 @@ func TestGetMetrics(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(3),
@@ -691,6 +809,7 @@ func TestGetMetrics(t *testing.T) {
 					return nil, errors.New("fail to get metric")
 				},
 			},
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(2),
@@ -747,6 +866,7 @@ func TestGetMetrics(t *testing.T) {
 					}, nil
 				},
 			},
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(2),
@@ -780,6 +900,7 @@ func TestGetMetrics(t *testing.T) {
 					return nil, errors.New("fail to get metric")
 				},
 			},
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(7),
@@ -836,6 +957,7 @@ func TestGetMetrics(t *testing.T) {
 					}, nil
 				},
 			},
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(7),
@@ -881,6 +1003,7 @@ func TestGetMetrics(t *testing.T) {
 					return nil, errors.New("fail to get external metric")
 				},
 			},
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(4),
@@ -1014,6 +1137,7 @@ func TestGetMetrics(t *testing.T) {
 					}, nil
 				},
 			},
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(4),
@@ -1192,6 +1316,7 @@ func TestGetMetrics(t *testing.T) {
 					}, nil
 				},
 			},
+			nil,
 			&appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{
 					Replicas: int32Ptr(4),
@@ -1246,10 +1371,11 @@ func TestGetMetrics(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
 			gatherer := metric.Gather{
-				Resource: test.resource,
-				Pods:     test.pods,
-				Object:   test.object,
-				External: test.external,
+				Resource:          test.resource,
+				Pods:              test.pods,
+				Object:            test.object,
+				External:          test.external,
+				ContainerResource: test.containerResource,
 			}
 			metrics, err := gatherer.GetMetrics(test.deployment, test.specs, test.namespace)
 			if !cmp.Equal(&err, &test.expectedErr, equateErrorMessage) {
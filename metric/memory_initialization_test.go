@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric_test
+
+import (
+	"testing"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+)
+
+func unreadyPod(name string, memoryRequest string, startedAgo time.Duration) *v1.Pod {
+	startTime := metav1.NewTime(time.Now().Add(-startedAgo))
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceMemory: resource.MustParse(memoryRequest),
+						},
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase:     v1.PodRunning,
+			StartTime: &startTime,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionFalse, LastTransitionTime: startTime},
+			},
+		},
+	}
+}
+
+func memoryReadyPod(name string, memoryRequest string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceMemory: resource.MustParse(memoryRequest),
+						},
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase:     v1.PodRunning,
+			StartTime: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestGatherer_GetMetrics_MemoryInitializationPeriod(t *testing.T) {
+	gatherer := &metric.Gatherer{
+		MetricsClient: &fakeMetricsClient{
+			getResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+				return metricsclient.PodMetricsInfo{
+					"pod-ready":   metricsclient.PodMetric{Value: 50 * 1024 * 1024},
+					"pod-warming": metricsclient.PodMetric{Value: 500 * 1024 * 1024},
+				}, time.Now(), nil
+			},
+		},
+		PodLister: statusPodLister(
+			memoryReadyPod("pod-ready", "100Mi"),
+			// Started a minute ago and still unready: within MemoryInitializationPeriod, so its high memory
+			// usage shouldn't count against the target the same way an unready CPU sample wouldn't.
+			unreadyPod("pod-warming", "100Mi", time.Minute),
+		),
+		MemoryInitializationPeriod: 5 * time.Minute,
+	}
+
+	targetUtilization := int32(80)
+	specs := []autoscaling.MetricSpec{
+		{
+			Type: autoscaling.ResourceMetricSourceType,
+			Resource: &autoscaling.ResourceMetricSource{
+				Name: v1.ResourceMemory,
+				Target: autoscaling.MetricTarget{
+					Type:               autoscaling.UtilizationMetricType,
+					AverageUtilization: &targetUtilization,
+				},
+			},
+		},
+	}
+
+	metrics, err := gatherer.GetMetrics(
+		autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"},
+		&autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 2}},
+		specs,
+		"test-namespace",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected a single gathered metric, got %+v", metrics)
+	}
+
+	resourceMetric := metrics[0].Resource
+	if resourceMetric.ReadyPodCount != 1 {
+		t.Errorf("expected ReadyPodCount 1 (the warming pod excluded), got %d", resourceMetric.ReadyPodCount)
+	}
+	if !resourceMetric.IgnoredPods.Has("pod-warming") {
+		t.Errorf("expected pod-warming to be classified as an ignored pod, got %+v", resourceMetric.IgnoredPods)
+	}
+	if _, found := resourceMetric.PodMetricsInfo["pod-warming"]; found {
+		t.Errorf("expected pod-warming's metric to be removed from PodMetricsInfo")
+	}
+}
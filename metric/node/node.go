@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// Gather (Node) provides functionality for retrieving cluster-wide resource metrics.
+type Gather struct {
+	MetricsClient MetricsClient
+	NodeLister    corelisters.NodeLister
+}
+
+// GetMetric lists the nodes matched by selector, sums their capacity for resource, sums their current usage of
+// resource (fetched through MetricsClient), and returns a Metric whose Utilization is the latter as a percentage
+// of the former.
+func (g *Gather) GetMetric(resource v1.ResourceName, selector labels.Selector) (*Metric, error) {
+	nodes, err := g.NodeLister.List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list nodes while calculating node count: %v", err)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes returned by selector while calculating cluster resource metric")
+	}
+
+	usage, timestamp, err := g.MetricsClient.GetNodeMetric(resource, selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get node metrics for resource %s: %v", resource, err)
+	}
+
+	var totalUsage, totalCapacity int64
+	for _, node := range nodes {
+		capacity, ok := node.Status.Capacity[resource]
+		if !ok {
+			continue
+		}
+		totalCapacity += capacity.MilliValue()
+		totalUsage += usage[node.Name]
+	}
+
+	if totalCapacity == 0 {
+		return nil, fmt.Errorf("no capacity reported for resource %s across the %d matched node(s)", resource, len(nodes))
+	}
+
+	return &Metric{
+		Utilization: totalUsage * 100 / totalCapacity,
+		NodeCount:   len(nodes),
+		Timestamp:   timestamp,
+	}, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/node"
+)
+
+type fakeMetricsClient struct {
+	getNodeMetricReactor func(resourceName v1.ResourceName, selector labels.Selector) (map[string]int64, time.Time, error)
+}
+
+func (f *fakeMetricsClient) GetNodeMetric(resourceName v1.ResourceName, selector labels.Selector) (map[string]int64, time.Time, error) {
+	return f.getNodeMetricReactor(resourceName, selector)
+}
+
+func nodeLister(nodes ...*v1.Node) corelisters.NodeLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, n := range nodes {
+		indexer.Add(n)
+	}
+	return corelisters.NewNodeLister(indexer)
+}
+
+func fakeNode(name string, cpuCapacity string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{
+				v1.ResourceCPU: resource.MustParse(cpuCapacity),
+			},
+		},
+	}
+}
+
+func TestGather_GetMetric(t *testing.T) {
+	equateErrorPresence := func(err error, expectErr bool) bool {
+		return (err != nil) == expectErr
+	}
+
+	tests := []struct {
+		description   string
+		metricsClient *fakeMetricsClient
+		nodeLister    corelisters.NodeLister
+		expected      *node.Metric
+		expectErr     bool
+	}{
+		{
+			description: "success, two nodes",
+			metricsClient: &fakeMetricsClient{
+				getNodeMetricReactor: func(resourceName v1.ResourceName, selector labels.Selector) (map[string]int64, time.Time, error) {
+					return map[string]int64{
+						"node-1": 1000,
+						"node-2": 3000,
+					}, time.Unix(0, 0), nil
+				},
+			},
+			nodeLister: nodeLister(fakeNode("node-1", "2"), fakeNode("node-2", "2")),
+			expected: &node.Metric{
+				Utilization: 100, // (1000 + 3000) / (2000 + 2000) * 100
+				NodeCount:   2,
+				Timestamp:   time.Unix(0, 0),
+			},
+		},
+		{
+			description: "metrics client fetch failure",
+			metricsClient: &fakeMetricsClient{
+				getNodeMetricReactor: func(resourceName v1.ResourceName, selector labels.Selector) (map[string]int64, time.Time, error) {
+					return nil, time.Time{}, errors.New("metrics API unavailable")
+				},
+			},
+			nodeLister: nodeLister(fakeNode("node-1", "2")),
+			expectErr:  true,
+		},
+		{
+			description:   "empty node list",
+			metricsClient: &fakeMetricsClient{},
+			nodeLister:    nodeLister(),
+			expectErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			gather := &node.Gather{
+				MetricsClient: test.metricsClient,
+				NodeLister:    test.nodeLister,
+			}
+
+			result, err := gather.GetMetric(v1.ResourceCPU, labels.Everything())
+			if !equateErrorPresence(err, test.expectErr) {
+				t.Fatalf("expected error presence %t, got error %v", test.expectErr, err)
+			}
+			if test.expectErr {
+				return
+			}
+
+			if result.Utilization != test.expected.Utilization {
+				t.Errorf("expected utilization %d, got %d", test.expected.Utilization, result.Utilization)
+			}
+			if result.NodeCount != test.expected.NodeCount {
+				t.Errorf("expected node count %d, got %d", test.expected.NodeCount, result.NodeCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package node provides a cluster-wide metric source, measuring aggregate node resource pressure (for example
+// overall CPU or memory utilization across the cluster, or a labelled subset of it) rather than the scale
+// target's own pods. The evaluate package has no evaluator for this source, so evaluating a gathered Node metric
+// fails with an unknown metric source type error.
+package node
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// MetricSourceType is a synthetic metric source type, not part of the autoscaling/v2 API, used the same way
+// prometheus.MetricSourceType is: a MetricSpec with this Type carries the target resource name and node selector
+// in its External field (see metric.Gatherer.getMetric), and is dispatched to a Gatherer rather than MetricsClient.
+const MetricSourceType = "Node"
+
+// MetricsClient describes how Gather retrieves each matched node's current resource usage.
+type MetricsClient interface {
+	// GetNodeMetric returns, for each node matched by selector, its milli-unit usage of resource, keyed by node
+	// name, alongside the time the measurement was taken.
+	GetNodeMetric(resource v1.ResourceName, selector labels.Selector) (map[string]int64, time.Time, error)
+}
+
+// Metric (Node) is a cluster-wide resource metric, aggregated across every node matched by a selector.
+type Metric struct {
+	// Utilization is the summed usage across matched nodes as a percentage of their summed capacity.
+	Utilization int64
+	// NodeCount is how many nodes were matched by the selector, including any that report no capacity for the
+	// resource and so do not contribute to Utilization.
+	NodeCount int
+	Timestamp time.Time
+}
+
+// Gatherer (Node) allows retrieval of a cluster-wide resource metric.
+type Gatherer interface {
+	GetMetric(resource v1.ResourceName, selector labels.Selector) (*Metric, error)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/node"
+)
+
+// nodeMetricsClient adapts the Kubernetes node metrics API to node.MetricsClient.
+type nodeMetricsClient struct {
+	client resourceclient.NodeMetricsesGetter
+}
+
+// NewNodeMetricsClient builds a node.MetricsClient backed by the Kubernetes node metrics API, the same API
+// resourceclient.PodMetricsesGetter is the pod-scoped counterpart of.
+func NewNodeMetricsClient(client resourceclient.NodeMetricsesGetter) node.MetricsClient {
+	return &nodeMetricsClient{client: client}
+}
+
+// GetNodeMetric returns, for each node matched by selector, its milli-unit usage of resource, keyed by node name.
+func (c *nodeMetricsClient) GetNodeMetric(resource v1.ResourceName, selector labels.Selector) (map[string]int64, time.Time, error) {
+	metrics, err := c.client.NodeMetricses().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	usage := map[string]int64{}
+	var timestamp time.Time
+	for _, nodeMetrics := range metrics.Items {
+		quantity, ok := nodeMetrics.Usage[resource]
+		if !ok {
+			continue
+		}
+		usage[nodeMetrics.Name] = quantity.MilliValue()
+		timestamp = nodeMetrics.Timestamp.Time
+	}
+
+	return usage, timestamp, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// defaultHistoryLength bounds how many samples are kept per series when Gather.HistoryLength is left unset.
+const defaultHistoryLength = 1000
+
+// Gather (Prediction) provides functionality for retrieving a forecast-augmented metric: it fetches the current
+// value through Current, appends it to a bounded in-memory ring buffer keyed by namespace/metricName/selector,
+// and calls Predictor with the resulting series to forecast horizon into the future.
+type Gather struct {
+	Current   CurrentGetter
+	Predictor Predictor
+	// HistoryLength bounds how many samples are kept per series. Zero uses defaultHistoryLength.
+	HistoryLength int
+
+	mu      sync.Mutex
+	history map[string][]Sample
+}
+
+// GetMetric retrieves the current value of metricName, records it against the series for
+// namespace/metricName/selector, and returns a Metric whose Utilization is the greater of the current value and
+// the Predictor's forecast for horizon ahead.
+func (g *Gather) GetMetric(metricName string, namespace string, selector labels.Selector, horizon time.Duration) (*Metric, error) {
+	current, timestamp, err := g.Current.GetCurrent(metricName, namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get current value for metric %s: %v", metricName, err)
+	}
+
+	series := g.record(seriesKey(namespace, metricName, selector), Sample{Timestamp: timestamp, Value: float64(current)})
+
+	predicted, err := g.Predictor.Predict(series, horizon)
+	if err != nil {
+		return nil, fmt.Errorf("unable to predict metric %s: %v", metricName, err)
+	}
+	predictedValue := int64(math.Round(predicted))
+
+	utilization := current
+	if predictedValue > utilization {
+		utilization = predictedValue
+	}
+
+	return &Metric{
+		Current:     current,
+		Predicted:   predictedValue,
+		Utilization: utilization,
+		Timestamp:   timestamp,
+	}, nil
+}
+
+// record appends sample to the series for key, trimming it down to HistoryLength (or defaultHistoryLength if
+// unset), and returns a copy of the resulting series safe for the caller to use without holding g.mu.
+func (g *Gather) record(key string, sample Sample) []Sample {
+	historyLength := g.HistoryLength
+	if historyLength <= 0 {
+		historyLength = defaultHistoryLength
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.history == nil {
+		g.history = make(map[string][]Sample)
+	}
+
+	series := append(g.history[key], sample)
+	if len(series) > historyLength {
+		series = series[len(series)-historyLength:]
+	}
+	g.history[key] = series
+
+	return append([]Sample(nil), series...)
+}
+
+func seriesKey(namespace, metricName string, selector labels.Selector) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, metricName, selector.String())
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// HoltWintersPredictor forecasts a series using triple exponential smoothing (level, trend and, when
+// SeasonLength is set, a seasonal component), suiting series that combine a trend with repeating seasonality,
+// for example steadily growing traffic with a daily cycle. With SeasonLength <= 1 the seasonal component is
+// skipped, reducing this to Holt's linear trend method (double exponential smoothing).
+type HoltWintersPredictor struct {
+	// Alpha, Beta and Gamma are the level, trend and seasonal smoothing factors, each in (0, 1); values closer to
+	// 1 weight recent samples more heavily. Zero (the typical unset value) defaults each to a moderate 0.3/0.1/0.1.
+	Alpha float64
+	Beta  float64
+	Gamma float64
+	// SeasonLength is the number of samples making up one full season, matched to whatever cadence samples are
+	// recorded at (for example, samples every 30s and a daily cycle gives a SeasonLength of 2880). SeasonLength
+	// <= 1 disables the seasonal component.
+	SeasonLength int
+}
+
+// Predict fits level, trend and (if SeasonLength > 1 and enough samples are available) seasonal components to
+// series, then projects them forward by the number of sample-intervals horizon corresponds to.
+func (p *HoltWintersPredictor) Predict(series []Sample, horizon time.Duration) (float64, error) {
+	if len(series) < 2 {
+		return 0, fmt.Errorf("at least 2 samples are required to predict a trend, got %d", len(series))
+	}
+
+	alpha, beta, gamma := p.Alpha, p.Beta, p.Gamma
+	if alpha <= 0 {
+		alpha = 0.3
+	}
+	if beta <= 0 {
+		beta = 0.1
+	}
+	if gamma <= 0 {
+		gamma = 0.1
+	}
+
+	values := make([]float64, len(series))
+	for i, sample := range series {
+		values[i] = sample.Value
+	}
+
+	seasonLength := p.SeasonLength
+	useSeasonal := seasonLength > 1 && len(values) >= 2*seasonLength
+
+	var level, trend float64
+	seasonal := make([]float64, seasonLength)
+
+	if useSeasonal {
+		firstSeasonAvg := average(values[:seasonLength])
+		secondSeasonAvg := average(values[seasonLength : 2*seasonLength])
+		level = firstSeasonAvg
+		trend = (secondSeasonAvg - firstSeasonAvg) / float64(seasonLength)
+		for i := 0; i < seasonLength; i++ {
+			seasonal[i] = values[i] - firstSeasonAvg
+		}
+	} else {
+		level = values[0]
+		trend = values[1] - values[0]
+	}
+
+	for i, value := range values {
+		var seasonalComponent float64
+		if useSeasonal {
+			seasonalComponent = seasonal[i%seasonLength]
+		}
+		prevLevel := level
+		level = alpha*(value-seasonalComponent) + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+		if useSeasonal {
+			seasonal[i%seasonLength] = gamma*(value-level) + (1-gamma)*seasonalComponent
+		}
+	}
+
+	steps := stepsAhead(series, horizon)
+	forecast := level + float64(steps)*trend
+	if useSeasonal {
+		forecast += seasonal[(len(values)+steps-1)%seasonLength]
+	}
+	return forecast, nil
+}
+
+func average(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+// stepsAhead converts horizon into a number of sample-intervals, based on the average interval observed across
+// series, so the smoothing loop's per-step trend applies the right number of times.
+func stepsAhead(series []Sample, horizon time.Duration) int {
+	totalInterval := series[len(series)-1].Timestamp.Sub(series[0].Timestamp)
+	if totalInterval <= 0 || len(series) < 2 {
+		return 1
+	}
+	avgInterval := totalInterval / time.Duration(len(series)-1)
+	if avgInterval <= 0 {
+		return 1
+	}
+	steps := int(math.Round(float64(horizon) / float64(avgInterval)))
+	if steps < 1 {
+		steps = 1
+	}
+	return steps
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// PeriodicPredictor forecasts a series with strong daily/weekly seasonality but no significant long-term trend,
+// by extracting the series' dominant frequency component (assumed to be 1/Period) via a direct discrete Fourier
+// transform and extrapolating that single component forward by horizon. This suits workloads whose load is
+// periodic but roughly stationary, such as traffic with a daily cycle that isn't otherwise growing.
+type PeriodicPredictor struct {
+	// Period is the expected periodicity of the series, for example 24*time.Hour for a daily cycle.
+	Period time.Duration
+}
+
+// Predict returns mean(series) plus the dominant Period-frequency component of series, projected forward by
+// horizon.
+func (p *PeriodicPredictor) Predict(series []Sample, horizon time.Duration) (float64, error) {
+	if len(series) < 2 {
+		return 0, fmt.Errorf("at least 2 samples are required to predict a periodic series, got %d", len(series))
+	}
+	if p.Period <= 0 {
+		return 0, fmt.Errorf("period must be positive")
+	}
+
+	mean := 0.0
+	for _, sample := range series {
+		mean += sample.Value
+	}
+	mean /= float64(len(series))
+
+	start := series[0].Timestamp
+	periodSeconds := p.Period.Seconds()
+
+	var real, imag float64
+	for _, sample := range series {
+		phase := 2 * math.Pi * sample.Timestamp.Sub(start).Seconds() / periodSeconds
+		real += (sample.Value - mean) * math.Cos(phase)
+		imag += (sample.Value - mean) * math.Sin(phase)
+	}
+	real *= 2 / float64(len(series))
+	imag *= 2 / float64(len(series))
+
+	amplitude := math.Hypot(real, imag)
+	phaseOffset := math.Atan2(imag, real)
+
+	targetElapsed := series[len(series)-1].Timestamp.Add(horizon).Sub(start).Seconds()
+	targetPhase := 2*math.Pi*targetElapsed/periodSeconds - phaseOffset
+
+	return mean + amplitude*math.Cos(targetPhase), nil
+}
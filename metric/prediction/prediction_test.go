@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prediction"
+)
+
+type fakeCurrentGetter struct {
+	value     int64
+	timestamp time.Time
+	err       error
+}
+
+func (f fakeCurrentGetter) GetCurrent(metricName string, namespace string, selector labels.Selector) (int64, time.Time, error) {
+	return f.value, f.timestamp, f.err
+}
+
+type fakePredictor struct {
+	predicted float64
+	err       error
+	lastCall  []prediction.Sample
+}
+
+func (f *fakePredictor) Predict(series []prediction.Sample, horizon time.Duration) (float64, error) {
+	f.lastCall = series
+	return f.predicted, f.err
+}
+
+func TestGather_GetMetric(t *testing.T) {
+	t.Run("utilization is the max of current and predicted", func(t *testing.T) {
+		gather := &prediction.Gather{
+			Current:   fakeCurrentGetter{value: 50, timestamp: time.Unix(0, 0)},
+			Predictor: &fakePredictor{predicted: 80},
+		}
+
+		result, err := gather.GetMetric("requests-per-second", "test-namespace", labels.Everything(), time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Utilization != 80 {
+			t.Errorf("expected utilization 80 (the predicted value), got %d", result.Utilization)
+		}
+		if result.Current != 50 {
+			t.Errorf("expected current 50, got %d", result.Current)
+		}
+
+		gather.Current = fakeCurrentGetter{value: 100, timestamp: time.Unix(1, 0)}
+		result, err = gather.GetMetric("requests-per-second", "test-namespace", labels.Everything(), time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Utilization != 100 {
+			t.Errorf("expected utilization 100 (the current value), got %d", result.Utilization)
+		}
+	})
+
+	t.Run("current value retrieval failure is propagated", func(t *testing.T) {
+		gather := &prediction.Gather{
+			Current:   fakeCurrentGetter{err: errors.New("metrics API unavailable")},
+			Predictor: &fakePredictor{},
+		}
+
+		_, err := gather.GetMetric("requests-per-second", "test-namespace", labels.Everything(), time.Minute)
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+
+	t.Run("each sample is appended to the series passed to the predictor", func(t *testing.T) {
+		predictor := &fakePredictor{}
+		gather := &prediction.Gather{
+			Current:   fakeCurrentGetter{value: 10, timestamp: time.Unix(0, 0)},
+			Predictor: predictor,
+		}
+
+		for i := int64(1); i <= 3; i++ {
+			gather.Current = fakeCurrentGetter{value: i * 10, timestamp: time.Unix(i, 0)}
+			if _, err := gather.GetMetric("requests-per-second", "test-namespace", labels.Everything(), time.Minute); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if len(predictor.lastCall) != 3 {
+			t.Fatalf("expected 3 accumulated samples on the final call, got %d", len(predictor.lastCall))
+		}
+		if predictor.lastCall[2].Value != 30 {
+			t.Errorf("expected the most recent sample to be 30, got %v", predictor.lastCall[2].Value)
+		}
+	})
+
+	t.Run("history is trimmed to HistoryLength", func(t *testing.T) {
+		predictor := &fakePredictor{}
+		gather := &prediction.Gather{
+			Predictor:     predictor,
+			HistoryLength: 2,
+		}
+
+		for i := int64(0); i < 5; i++ {
+			gather.Current = fakeCurrentGetter{value: i, timestamp: time.Unix(i, 0)}
+			if _, err := gather.GetMetric("requests-per-second", "test-namespace", labels.Everything(), time.Minute); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if len(predictor.lastCall) != 2 {
+			t.Fatalf("expected history trimmed to 2 samples, got %d", len(predictor.lastCall))
+		}
+		if predictor.lastCall[len(predictor.lastCall)-1].Value != 4 {
+			t.Errorf("expected the last retained sample to be the most recent one, got %v", predictor.lastCall[len(predictor.lastCall)-1].Value)
+		}
+	})
+}
+
+func TestPeriodicPredictor_Predict(t *testing.T) {
+	predictor := &prediction.PeriodicPredictor{Period: 10 * time.Second}
+
+	start := time.Unix(0, 0)
+	var series []prediction.Sample
+	// A sine wave with period 10s, amplitude 5, mean 20.
+	for i := 0; i < 40; i++ {
+		ts := start.Add(time.Duration(i) * time.Second)
+		value := 20 + 5*math.Sin(2*math.Pi*float64(i)/10)
+		series = append(series, prediction.Sample{Timestamp: ts, Value: value})
+	}
+
+	// Predict exactly one full period ahead of the last sample: the forecast should land back at the same phase
+	// as the last sample, i.e. close to its value.
+	predicted, err := predictor.Predict(series, 10*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lastValue := series[len(series)-1].Value
+	if math.Abs(predicted-lastValue) > 0.5 {
+		t.Errorf("expected a prediction one period ahead to be close to the last sample (%v), got %v", lastValue, predicted)
+	}
+}
+
+func TestHoltWintersPredictor_Predict(t *testing.T) {
+	t.Run("extrapolates a linear trend", func(t *testing.T) {
+		predictor := &prediction.HoltWintersPredictor{Alpha: 0.9, Beta: 0.9}
+
+		start := time.Unix(0, 0)
+		var series []prediction.Sample
+		for i := 0; i < 20; i++ {
+			series = append(series, prediction.Sample{
+				Timestamp: start.Add(time.Duration(i) * time.Second),
+				Value:     float64(10 + i*2),
+			})
+		}
+
+		predicted, err := predictor.Predict(series, 5*time.Second)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		lastValue := series[len(series)-1].Value
+		if predicted <= lastValue {
+			t.Errorf("expected a forecast further along the upward trend than the last sample (%v), got %v", lastValue, predicted)
+		}
+	})
+
+	t.Run("requires at least 2 samples", func(t *testing.T) {
+		predictor := &prediction.HoltWintersPredictor{}
+		_, err := predictor.Predict([]prediction.Sample{{Timestamp: time.Unix(0, 0), Value: 1}}, time.Second)
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+}
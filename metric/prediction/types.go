@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package prediction provides a forecasting metric source, wrapping an existing Resource, Pods or External metric
+// with a Predictor that projects the series forward by a horizon, reporting the greater of the current and
+// forecast values. The evaluate package has no evaluator for this source, so evaluating a gathered Prediction
+// metric fails with an unknown metric source type error.
+package prediction
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// MetricSourceType is a synthetic metric source type, not part of the autoscaling/v2 API, used the same way
+// prometheus.MetricSourceType is: a MetricSpec with this Type carries the underlying metric's name and target in
+// its External field (see metric.Gatherer.getMetric), and is dispatched to a Gatherer rather than MetricsClient.
+const MetricSourceType = "Prediction"
+
+// Sample is a single observation of a metric's value at a point in time, the unit Predictor implementations
+// operate on.
+type Sample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// Predictor forecasts a metric series horizon into the future. Implementations are free to use whatever
+// forecasting technique suits the series they're designed for (see PeriodicPredictor and HoltWintersPredictor).
+type Predictor interface {
+	Predict(series []Sample, horizon time.Duration) (float64, error)
+}
+
+// CurrentGetter retrieves the instantaneous (non-predicted) value of a metric, the same way the underlying
+// Resource/Pods/External gatherer it wraps would. Gather calls this once per GetMetric call to both report the
+// current value and extend the series the Predictor forecasts from.
+type CurrentGetter interface {
+	GetCurrent(metricName string, namespace string, selector labels.Selector) (int64, time.Time, error)
+}
+
+// Metric (Prediction) is a metric source whose Utilization is the greater of the instantaneous value and a
+// forecast of where the series is headed, so callers evaluating it scale on whichever is more urgent.
+type Metric struct {
+	Current     int64
+	Predicted   int64
+	Utilization int64
+	Timestamp   time.Time
+}
+
+// Gatherer (Prediction) allows retrieval of a forecast-augmented metric.
+type Gatherer interface {
+	GetMetric(metricName string, namespace string, selector labels.Selector, horizon time.Duration) (*Metric, error)
+}
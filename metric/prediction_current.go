@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prediction"
+)
+
+// predictionCurrentGetter adapts a MetricsClient's per-pod raw metric query to prediction.CurrentGetter, summing
+// across pods the same way getExternalMetric sums an External metric's per-pod values into a single utilization.
+type predictionCurrentGetter struct {
+	metricsClient MetricsClient
+}
+
+// NewPredictionCurrentGetter builds a prediction.CurrentGetter that reports the instantaneous value of a Pods
+// metric by summing metricsClient.GetRawMetric across every pod matched by selector. This is the total across
+// those pods, not the per-pod average a Pods metric source is evaluated on.
+func NewPredictionCurrentGetter(metricsClient MetricsClient) prediction.CurrentGetter {
+	return &predictionCurrentGetter{metricsClient: metricsClient}
+}
+
+// GetCurrent returns the summed current value of metricName across every pod matched by selector.
+func (g *predictionCurrentGetter) GetCurrent(metricName string, namespace string, selector labels.Selector) (int64, time.Time, error) {
+	metrics, timestamp, err := g.metricsClient.GetRawMetric(metricName, namespace, selector, labels.Everything())
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var total int64
+	for _, podMetric := range metrics {
+		total += podMetric.Value
+	}
+
+	return total, timestamp, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package prometheus gathers a metric by executing a user-supplied PromQL expression directly against
+// Prometheus, rather than going through any of the Kubernetes metrics/custom-metrics/external-metrics adapter
+// APIs (compare metric/prometheusclient, which answers the standard Resource/Pods/Object/External queries by
+// templating PromQL, but still requires the spec to be one of the Kubernetes-defined metric source types). This
+// lets users scale directly off an arbitrary RED/USE-style query without deploying prometheus-adapter at all.
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	autoscaling "k8s.io/api/autoscaling/v2"
+)
+
+// MetricSourceType is a synthetic autoscaling.MetricSourceType marking a MetricSpec as a direct PromQL query.
+// Neither autoscaling/v2 nor the older autoscaling/v2beta2 has a native metric source slot for this, so the
+// query is instead carried in Spec.External.Metric.Name and the target in Spec.External.Target, reusing the
+// shape of the built-in External metric source rather than forking the Kubernetes API types.
+const MetricSourceType autoscaling.MetricSourceType = "Prometheus"
+
+// Gatherer (Prometheus) allows retrieval of a metric computed by executing a PromQL query.
+type Gatherer interface {
+	GetMetric(query string) (*Metric, error)
+}
+
+// Metric (Prometheus) is the scalar result of evaluating a PromQL query at a point in time.
+type Metric struct {
+	Value     int64
+	Timestamp time.Time
+}
+
+// Client describes how Gather executes a PromQL query, matching the subset of promv1.API that Gather needs.
+type Client interface {
+	Query(ctx context.Context, query string, ts time.Time) (model.Value, promv1.Warnings, error)
+}
+
+// Gather (Prometheus) provides functionality for retrieving a metric computed by a PromQL query, enforcing
+// QueryTimeout on every query.
+type Gather struct {
+	Client Client
+	// QueryTimeout bounds how long a single query is allowed to run. A zero value means no timeout.
+	QueryTimeout time.Duration
+}
+
+// GetMetric executes query against Prometheus, treating the result as either a single scalar or the first
+// sample of a vector result.
+func (g *Gather) GetMetric(query string) (*Metric, error) {
+	ctx := context.Background()
+	if g.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.QueryTimeout)
+		defer cancel()
+	}
+
+	result, _, err := g.Client.Query(ctx, query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("unable to query prometheus with query %q: %v", query, err)
+	}
+
+	switch value := result.(type) {
+	case *model.Scalar:
+		return &Metric{
+			Value:     int64(value.Value),
+			Timestamp: value.Timestamp.Time(),
+		}, nil
+	case model.Vector:
+		if len(value) == 0 {
+			return nil, fmt.Errorf("no samples returned for prometheus query %q", query)
+		}
+		return &Metric{
+			Value:     int64(value[0].Value),
+			Timestamp: value[0].Timestamp.Time(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("expected a scalar or vector result from prometheus query %q, got %T", query, result)
+	}
+}
+
+// RetryRoundTripper wraps an http.RoundTripper, retrying a request that fails with a network error or a 5xx
+// response up to MaxRetries times, doubling BaseDelay between each attempt. Set it as the Transport of the
+// http.Client passed when constructing the Prometheus API client, so a transient Prometheus or network hiccup
+// doesn't immediately fail a gather.
+type RetryRoundTripper struct {
+	// Next is the underlying RoundTripper to retry. Defaults to http.DefaultTransport if nil.
+	Next http.RoundTripper
+	// MaxRetries is the number of retries attempted after the initial request, so up to MaxRetries+1 requests
+	// may be made in total.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry; it doubles after each subsequent retry.
+	BaseDelay time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *RetryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	delay := r.BaseDelay
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = next.RoundTrip(req)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if attempt >= r.MaxRetries {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+)
+
+type fakeClient struct {
+	query func(ctx context.Context, query string, ts time.Time) (model.Value, promv1.Warnings, error)
+}
+
+func (f *fakeClient) Query(ctx context.Context, query string, ts time.Time) (model.Value, promv1.Warnings, error) {
+	return f.query(ctx, query, ts)
+}
+
+func TestGather_GetMetric(t *testing.T) {
+	t.Run("scalar result", func(t *testing.T) {
+		gather := &prometheus.Gather{
+			Client: &fakeClient{
+				query: func(ctx context.Context, query string, ts time.Time) (model.Value, promv1.Warnings, error) {
+					return &model.Scalar{Value: 42, Timestamp: model.TimeFromUnix(100)}, nil, nil
+				},
+			},
+		}
+
+		metric, err := gather.GetMetric("sum(queue_length)")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if metric.Value != 42 {
+			t.Errorf("expected value 42, got %d", metric.Value)
+		}
+	})
+
+	t.Run("vector result uses the first sample", func(t *testing.T) {
+		gather := &prometheus.Gather{
+			Client: &fakeClient{
+				query: func(ctx context.Context, query string, ts time.Time) (model.Value, promv1.Warnings, error) {
+					return model.Vector{
+						&model.Sample{Value: 7, Timestamp: model.TimeFromUnix(100)},
+					}, nil, nil
+				},
+			},
+		}
+
+		metric, err := gather.GetMetric("up")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if metric.Value != 7 {
+			t.Errorf("expected value 7, got %d", metric.Value)
+		}
+	})
+
+	t.Run("empty vector result is an error", func(t *testing.T) {
+		gather := &prometheus.Gather{
+			Client: &fakeClient{
+				query: func(ctx context.Context, query string, ts time.Time) (model.Value, promv1.Warnings, error) {
+					return model.Vector{}, nil, nil
+				},
+			},
+		}
+
+		_, err := gather.GetMetric("up")
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+
+	t.Run("query error is propagated", func(t *testing.T) {
+		gather := &prometheus.Gather{
+			Client: &fakeClient{
+				query: func(ctx context.Context, query string, ts time.Time) (model.Value, promv1.Warnings, error) {
+					return nil, nil, errors.New("connection refused")
+				},
+			},
+		}
+
+		_, err := gather.GetMetric("up")
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+}
+
+func TestRetryRoundTripper_RoundTrip(t *testing.T) {
+	t.Run("retries on 5xx then succeeds", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		roundTripper := &prometheus.RetryRoundTripper{
+			MaxRetries: 3,
+			BaseDelay:  time.Millisecond,
+		}
+		client := &http.Client{Transport: roundTripper}
+
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("gives up after MaxRetries", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		roundTripper := &prometheus.RetryRoundTripper{
+			MaxRetries: 2,
+			BaseDelay:  time.Millisecond,
+		}
+		client := &http.Client{Transport: roundTripper}
+
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", resp.StatusCode)
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts (1 initial + 2 retries), got %d", attempts)
+		}
+	})
+}
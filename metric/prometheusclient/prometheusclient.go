@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package prometheusclient provides a metric.MetricsClient implementation that queries Prometheus directly over
+// its HTTP API, rather than going through the Kubernetes metrics/custom-metrics/external-metrics adapter APIs. This
+// removes the need to run a prometheus-adapter (or similar) in front of Prometheus purely to make its data visible
+// to the autoscaler.
+package prometheusclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+
+	autoscaling "k8s.io/api/autoscaling/v2"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// QueryTemplater builds a PromQL query string for a named metric, namespace and label selector. Templates allow
+// users to control exactly how a metric name maps to a PromQL query, since there's no standard convention for this
+// the way there is for the custom/external metrics adapters.
+type QueryTemplater interface {
+	ResourceQuery(resource v1.ResourceName, namespace string, selector labels.Selector) string
+	ContainerResourceQuery(resource v1.ResourceName, namespace string, selector labels.Selector, container string) string
+	RawQuery(metricName string, namespace string, selector labels.Selector, metricSelector labels.Selector) string
+	ObjectQuery(metricName string, namespace string, objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) string
+	ExternalQuery(metricName string, namespace string, selector labels.Selector) string
+}
+
+// Client is a metric.MetricsClient implementation that runs PromQL queries built by a QueryTemplater directly
+// against a Prometheus HTTP API, rather than relying on an adapter to translate Kubernetes metric requests into
+// PromQL on the autoscaler's behalf.
+type Client struct {
+	API       promv1.API
+	Templater QueryTemplater
+}
+
+// GetResourceMetric runs the templated resource query and returns one sample per pod, keyed by pod name.
+func (c *Client) GetResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return c.queryPerPod(c.Templater.ResourceQuery(resource, namespace, selector))
+}
+
+// GetContainerResourceMetric runs the templated container resource query and returns one sample per pod, keyed by
+// pod name, scoped to the named container.
+func (c *Client) GetContainerResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return c.queryPerPod(c.Templater.ContainerResourceQuery(resource, namespace, selector, container))
+}
+
+// GetRawMetric runs the templated pods metric query and returns one sample per pod, keyed by pod name.
+func (c *Client) GetRawMetric(metricName string, namespace string, selector labels.Selector, metricSelector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return c.queryPerPod(c.Templater.RawQuery(metricName, namespace, selector, metricSelector))
+}
+
+// GetObjectMetric runs the templated object metric query and returns its single scalar value.
+func (c *Client) GetObjectMetric(metricName string, namespace string, objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) (int64, time.Time, error) {
+	return c.queryScalar(c.Templater.ObjectQuery(metricName, namespace, objectRef, metricSelector))
+}
+
+// GetExternalMetric runs the templated external metric query, returning every sample in the result vector.
+func (c *Client) GetExternalMetric(metricName string, namespace string, selector labels.Selector) ([]int64, time.Time, error) {
+	result, _, err := c.API.Query(context.Background(), c.Templater.ExternalQuery(metricName, namespace, selector), time.Now())
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to query prometheus: %v", err)
+	}
+
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("expected a vector result from prometheus, got %T", result)
+	}
+
+	values := make([]int64, len(vector))
+	timestamp := time.Now()
+	for i, sample := range vector {
+		values[i] = int64(sample.Value)
+		timestamp = sample.Timestamp.Time()
+	}
+
+	return values, timestamp, nil
+}
+
+func (c *Client) queryPerPod(query string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	result, _, err := c.API.Query(context.Background(), query, time.Now())
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to query prometheus: %v", err)
+	}
+
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("expected a vector result from prometheus, got %T", result)
+	}
+
+	info := make(metricsclient.PodMetricsInfo, len(vector))
+	timestamp := time.Now()
+	for _, sample := range vector {
+		podName, ok := sample.Metric["pod"]
+		if !ok {
+			continue
+		}
+		timestamp = sample.Timestamp.Time()
+		info[string(podName)] = metricsclient.PodMetric{
+			Timestamp: timestamp,
+			Value:     int64(sample.Value),
+		}
+	}
+
+	return info, timestamp, nil
+}
+
+func (c *Client) queryScalar(query string) (int64, time.Time, error) {
+	result, _, err := c.API.Query(context.Background(), query, time.Now())
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to query prometheus: %v", err)
+	}
+
+	vector, ok := result.(model.Vector)
+	if !ok || len(vector) == 0 {
+		return 0, time.Time{}, fmt.Errorf("expected a single sample vector result from prometheus, got %T", result)
+	}
+
+	sample := vector[0]
+	return int64(sample.Value), sample.Timestamp.Time(), nil
+}
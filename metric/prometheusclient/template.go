@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheusclient
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	autoscaling "k8s.io/api/autoscaling/v2"
+)
+
+const (
+	defaultNamespaceLabel = "namespace"
+	defaultPodLabel       = "pod"
+	defaultContainerLabel = "container"
+)
+
+// DefaultQueryTemplater is a QueryTemplater covering the common case: each metric name maps directly to a PromQL
+// series of the same name, scoped to namespace/container labels matching the usual kube-state-metrics /
+// cAdvisor naming convention. Users whose metrics don't follow that convention should implement QueryTemplater
+// themselves instead of reconfiguring this one.
+type DefaultQueryTemplater struct {
+	// NamespaceLabel is the label series are filtered by namespace with. Defaults to "namespace".
+	NamespaceLabel string
+	// PodLabel defaults to "pod". No query built by this templater reads it, and Client keys per-pod results by
+	// the "pod" label whatever it is set to.
+	PodLabel string
+	// ContainerLabel is the label ContainerResource queries additionally filter by. Defaults to "container".
+	ContainerLabel string
+}
+
+// ResourceQuery builds a per-pod query for a Resource metric source.
+func (t *DefaultQueryTemplater) ResourceQuery(resource v1.ResourceName, namespace string, selector labels.Selector) string {
+	return fmt.Sprintf("%s{%s}", resource, t.matchers(namespace, selector, ""))
+}
+
+// ContainerResourceQuery builds a per-pod query for a ContainerResource metric source, additionally scoped to
+// container.
+func (t *DefaultQueryTemplater) ContainerResourceQuery(resource v1.ResourceName, namespace string, selector labels.Selector, container string) string {
+	return fmt.Sprintf("%s{%s}", resource, t.matchers(namespace, selector, container))
+}
+
+// RawQuery builds a per-pod query for a Pods metric source.
+func (t *DefaultQueryTemplater) RawQuery(metricName string, namespace string, selector labels.Selector, metricSelector labels.Selector) string {
+	return fmt.Sprintf("%s{%s}", metricName, t.matchers(namespace, mergeSelectors(selector, metricSelector), ""))
+}
+
+// ObjectQuery builds a namespace-scoped sum query for an Object metric source, ignoring the described object
+// itself since there's no standard label naming convention to look it up by; it relies on namespace and
+// metricSelector to scope the series.
+func (t *DefaultQueryTemplater) ObjectQuery(metricName string, namespace string, objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) string {
+	return fmt.Sprintf("sum(%s{%s})", metricName, t.matchers(namespace, metricSelector, ""))
+}
+
+// ExternalQuery builds a query for an External metric source, scoped to namespace and the metric's own selector.
+func (t *DefaultQueryTemplater) ExternalQuery(metricName string, namespace string, selector labels.Selector) string {
+	return fmt.Sprintf("%s{%s}", metricName, t.matchers(namespace, selector, ""))
+}
+
+func (t *DefaultQueryTemplater) namespaceLabel() string {
+	if t.NamespaceLabel == "" {
+		return defaultNamespaceLabel
+	}
+	return t.NamespaceLabel
+}
+
+func (t *DefaultQueryTemplater) podLabel() string {
+	if t.PodLabel == "" {
+		return defaultPodLabel
+	}
+	return t.PodLabel
+}
+
+func (t *DefaultQueryTemplater) containerLabel() string {
+	if t.ContainerLabel == "" {
+		return defaultContainerLabel
+	}
+	return t.ContainerLabel
+}
+
+// matchers builds the PromQL label matcher list scoping a query to namespace, selector, and (if non-empty)
+// container.
+func (t *DefaultQueryTemplater) matchers(namespace string, selector labels.Selector, container string) string {
+	parts := []string{fmt.Sprintf("%s=%q", t.namespaceLabel(), namespace)}
+	if container != "" {
+		parts = append(parts, fmt.Sprintf("%s=%q", t.containerLabel(), container))
+	}
+	parts = append(parts, selectorMatchers(selector)...)
+	return strings.Join(parts, ",")
+}
+
+// selectorMatchers converts a label selector's requirements into PromQL label matcher fragments. Exists/DoesNotExist
+// requirements have no direct PromQL equivalent and are skipped rather than producing an invalid query.
+func selectorMatchers(selector labels.Selector) []string {
+	if selector == nil {
+		return nil
+	}
+	requirements, selectable := selector.Requirements()
+	if !selectable {
+		return nil
+	}
+
+	var matchers []string
+	for _, requirement := range requirements {
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals:
+			matchers = append(matchers, fmt.Sprintf("%s=%q", requirement.Key(), requirement.Values().List()[0]))
+		case selection.NotEquals:
+			matchers = append(matchers, fmt.Sprintf("%s!=%q", requirement.Key(), requirement.Values().List()[0]))
+		case selection.In:
+			matchers = append(matchers, fmt.Sprintf("%s=~%q", requirement.Key(), "^("+strings.Join(requirement.Values().List(), "|")+")$"))
+		case selection.NotIn:
+			matchers = append(matchers, fmt.Sprintf("%s!~%q", requirement.Key(), "^("+strings.Join(requirement.Values().List(), "|")+")$"))
+		}
+	}
+	return matchers
+}
+
+// mergeSelectors combines selector and metricSelector into a single selector, the same way Pods metric sources
+// scope a query by both the scale target's pod selector and the metric's own selector.
+func mergeSelectors(selector labels.Selector, metricSelector labels.Selector) labels.Selector {
+	if selector == nil {
+		return metricSelector
+	}
+	if metricSelector == nil {
+		return selector
+	}
+	requirements, _ := metricSelector.Requirements()
+	return selector.Add(requirements...)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package render provides a kubectl-top style human-readable rendering of gathered metrics, for use by operators
+// inspecting what the autoscaler saw rather than piping JSON straight to evaluation.
+package render
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	autoscaling "k8s.io/api/autoscaling/v2"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+)
+
+// Table writes a human-readable table of the gathered metrics to w, one row per CombinedMetric, in the same
+// tab-aligned style as `kubectl top pods`.
+func Table(w io.Writer, metrics []*metric.CombinedMetric) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tNAME\tCURRENT\tTARGET\tREPLICAS")
+	for _, m := range metrics {
+		if m == nil {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", m.Spec.Type, metricName(m), current(m), target(m), m.CurrentReplicas)
+	}
+	return tw.Flush()
+}
+
+func metricName(m *metric.CombinedMetric) string {
+	switch m.Spec.Type {
+	case autoscaling.ResourceMetricSourceType:
+		return string(m.Spec.Resource.Name)
+	case autoscaling.ContainerResourceMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Spec.ContainerResource.Container, m.Spec.ContainerResource.Name)
+	case autoscaling.PodsMetricSourceType:
+		return m.Spec.Pods.Metric.Name
+	case autoscaling.ObjectMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Spec.Object.DescribedObject.Kind, m.Spec.Object.Metric.Name)
+	case autoscaling.ExternalMetricSourceType:
+		return m.Spec.External.Metric.Name
+	default:
+		return "<unknown>"
+	}
+}
+
+func current(m *metric.CombinedMetric) string {
+	switch m.Spec.Type {
+	case autoscaling.ResourceMetricSourceType:
+		return averageResourceValue(m.Resource)
+	case autoscaling.ContainerResourceMetricSourceType:
+		return averageResourceValue(m.ContainerResource)
+	case autoscaling.PodsMetricSourceType:
+		return averagePodsValue(m.Pods)
+	case autoscaling.ObjectMetricSourceType:
+		return fmt.Sprintf("%d", m.Object.Utilization)
+	case autoscaling.ExternalMetricSourceType:
+		return fmt.Sprintf("%d", m.External.Utilization)
+	default:
+		return "<unknown>"
+	}
+}
+
+func target(m *metric.CombinedMetric) string {
+	switch m.Spec.Type {
+	case autoscaling.ResourceMetricSourceType:
+		return resourceTarget(m.Spec.Resource.Target)
+	case autoscaling.ContainerResourceMetricSourceType:
+		return resourceTarget(m.Spec.ContainerResource.Target)
+	case autoscaling.PodsMetricSourceType:
+		return fmt.Sprintf("%dm", m.Spec.Pods.Target.AverageValue.MilliValue())
+	case autoscaling.ObjectMetricSourceType:
+		if m.Spec.Object.Target.Value != nil {
+			return fmt.Sprintf("%dm", m.Spec.Object.Target.Value.MilliValue())
+		}
+		return fmt.Sprintf("%dm", m.Spec.Object.Target.AverageValue.MilliValue())
+	case autoscaling.ExternalMetricSourceType:
+		if m.Spec.External.Target.Value != nil {
+			return fmt.Sprintf("%dm", m.Spec.External.Target.Value.MilliValue())
+		}
+		return fmt.Sprintf("%dm", m.Spec.External.Target.AverageValue.MilliValue())
+	default:
+		return "<unknown>"
+	}
+}
+
+func resourceTarget(target autoscaling.MetricTarget) string {
+	if target.AverageUtilization != nil {
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	}
+	return fmt.Sprintf("%dm", target.AverageValue.MilliValue())
+}
+
+func averageResourceValue(m *metric.ResourceMetric) string {
+	if m == nil || m.ReadyPodCount == 0 {
+		return "<unknown>"
+	}
+	sum := int64(0)
+	for _, podMetric := range m.PodMetricsInfo {
+		sum += podMetric.Value
+	}
+	return fmt.Sprintf("%dm", sum/m.ReadyPodCount)
+}
+
+func averagePodsValue(m *metric.PodsMetric) string {
+	if m == nil || m.ReadyPodCount == 0 {
+		return "<unknown>"
+	}
+	sum := int64(0)
+	for _, podMetric := range m.PodMetricsInfo {
+		sum += podMetric.Value
+	}
+	return fmt.Sprintf("%dm", sum/m.ReadyPodCount)
+}
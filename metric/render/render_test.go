@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/render"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+)
+
+func TestTable(t *testing.T) {
+	utilization := int32(50)
+	metrics := []*metric.CombinedMetric{
+		{
+			CurrentReplicas: 3,
+			Spec: autoscaling.MetricSpec{
+				Type: autoscaling.ResourceMetricSourceType,
+				Resource: &autoscaling.ResourceMetricSource{
+					Name: "cpu",
+					Target: autoscaling.MetricTarget{
+						AverageUtilization: &utilization,
+					},
+				},
+			},
+			Resource: &metric.ResourceMetric{
+				PodMetricsInfo: metricsclient.PodMetricsInfo{
+					"pod-a": {Value: 100},
+				},
+				ReadyPodCount: 1,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := render.Table(&buf, metrics)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "cpu") {
+		t.Errorf("expected output to contain metric name %q, got:\n%s", "cpu", output)
+	}
+	if !strings.Contains(output, "50%") {
+		t.Errorf("expected output to contain target %q, got:\n%s", "50%", output)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	autoscaling "k8s.io/api/autoscaling/v2"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/node"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prediction"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
+)
+
+// buildMetricStatus derives the Kubernetes-style MetricStatus for a successfully gathered combinedMetric, matching
+// the shape the HorizontalPodAutoscaler's Status.CurrentMetrics would hold for the same spec.
+func buildMetricStatus(spec autoscaling.MetricSpec, combinedMetric *CombinedMetric) autoscaling.MetricStatus {
+	status := autoscaling.MetricStatus{Type: spec.Type}
+
+	switch spec.Type {
+	case autoscaling.ResourceMetricSourceType:
+		status.Resource = &autoscaling.ResourceMetricStatus{
+			Name:    spec.Resource.Name,
+			Current: resourceMetricValueStatus(combinedMetric.Resource),
+		}
+	case autoscaling.ContainerResourceMetricSourceType:
+		status.ContainerResource = &autoscaling.ContainerResourceMetricStatus{
+			Name:      spec.ContainerResource.Name,
+			Container: spec.ContainerResource.Container,
+			Current:   resourceMetricValueStatus(combinedMetric.ContainerResource),
+		}
+	case autoscaling.PodsMetricSourceType:
+		status.Pods = &autoscaling.PodsMetricStatus{
+			Metric:  spec.Pods.Metric,
+			Current: podsMetricValueStatus(combinedMetric.Pods),
+		}
+	case autoscaling.ObjectMetricSourceType:
+		status.Object = &autoscaling.ObjectMetricStatus{
+			DescribedObject: spec.Object.DescribedObject,
+			Metric:          spec.Object.Metric,
+			Current:         perPodOrValueStatus(spec.Object.Target.Type, combinedMetric.Object.Utilization),
+		}
+	case autoscaling.ExternalMetricSourceType:
+		status.External = &autoscaling.ExternalMetricStatus{
+			Metric:  spec.External.Metric,
+			Current: perPodOrValueStatus(spec.External.Target.Type, combinedMetric.External.Utilization),
+		}
+	case prometheus.MetricSourceType:
+		// Prometheus is a synthetic metric source, carried in the External slot of the MetricSpec (see
+		// getMetric), with no native MetricStatus counterpart; report it as an ExternalMetricStatus too so
+		// callers get a consistent Current value regardless of which metric source produced it.
+		utilization := int64(0)
+		if combinedMetric.Prometheus != nil {
+			utilization = combinedMetric.Prometheus.Value
+		}
+		status.External = &autoscaling.ExternalMetricStatus{
+			Metric:  spec.External.Metric,
+			Current: perPodOrValueStatus(spec.External.Target.Type, utilization),
+		}
+	case prediction.MetricSourceType:
+		// Prediction is also carried in the External slot (see getMetric); report its forecast-augmented
+		// Utilization the same way Prometheus's synthetic metric is reported.
+		utilization := int64(0)
+		if combinedMetric.Prediction != nil {
+			utilization = combinedMetric.Prediction.Utilization
+		}
+		status.External = &autoscaling.ExternalMetricStatus{
+			Metric:  spec.External.Metric,
+			Current: perPodOrValueStatus(spec.External.Target.Type, utilization),
+		}
+	case node.MetricSourceType:
+		// Node is also carried in the External slot (see getMetric); report its cluster-wide utilization
+		// percentage the same way Prometheus's and Prediction's synthetic metrics are reported.
+		utilization := int64(0)
+		if combinedMetric.Node != nil {
+			utilization = combinedMetric.Node.Utilization
+		}
+		status.External = &autoscaling.ExternalMetricStatus{
+			Metric:  spec.External.Metric,
+			Current: perPodOrValueStatus(spec.External.Target.Type, utilization),
+		}
+	}
+
+	return status
+}
+
+// resourceMetricValueStatus summarises a ResourceMetric's current usage the same way upstream's
+// GetResourceUtilizationRatio does: the total usage divided by the pod count as an AverageValue, and, when
+// requests are known (i.e. this isn't a raw AverageValue-only gather), the total usage as a percentage of the
+// total requests as an AverageUtilization.
+func resourceMetricValueStatus(resourceMetric *ResourceMetric) autoscaling.MetricValueStatus {
+	if resourceMetric == nil || resourceMetric.ReadyPodCount == 0 {
+		return autoscaling.MetricValueStatus{}
+	}
+
+	totalUsage := int64(0)
+	for _, podMetric := range resourceMetric.PodMetricsInfo {
+		totalUsage += podMetric.Value
+	}
+	averageValue := totalUsage / resourceMetric.ReadyPodCount
+
+	status := autoscaling.MetricValueStatus{
+		AverageValue: k8sresource.NewMilliQuantity(averageValue, k8sresource.DecimalSI),
+	}
+
+	if resourceMetric.Requests == nil {
+		return status
+	}
+
+	totalRequests, requestedUsage := int64(0), int64(0)
+	for podName, podMetric := range resourceMetric.PodMetricsInfo {
+		request, ok := resourceMetric.Requests[podName]
+		if !ok {
+			continue
+		}
+		totalRequests += request
+		requestedUsage += podMetric.Value
+	}
+	if totalRequests == 0 {
+		return status
+	}
+
+	averageUtilization := int32(requestedUsage * 100 / totalRequests)
+	status.AverageUtilization = &averageUtilization
+	return status
+}
+
+// podsMetricValueStatus summarises a PodsMetric's current usage as an AverageValue across its ready pods.
+func podsMetricValueStatus(podsMetric *PodsMetric) autoscaling.MetricValueStatus {
+	if podsMetric == nil || podsMetric.ReadyPodCount == 0 {
+		return autoscaling.MetricValueStatus{}
+	}
+
+	totalUsage := int64(0)
+	for _, podMetric := range podsMetric.PodMetricsInfo {
+		totalUsage += podMetric.Value
+	}
+
+	return autoscaling.MetricValueStatus{
+		AverageValue: k8sresource.NewMilliQuantity(totalUsage/podsMetric.ReadyPodCount, k8sresource.DecimalSI),
+	}
+}
+
+// perPodOrValueStatus reports utilization as an AverageValue when targetType is AverageValueMetricType, or a plain
+// Value otherwise, matching how Object and External metrics are targeted.
+func perPodOrValueStatus(targetType autoscaling.MetricTargetType, utilization int64) autoscaling.MetricValueStatus {
+	quantity := k8sresource.NewMilliQuantity(utilization, k8sresource.DecimalSI)
+	if targetType == autoscaling.AverageValueMetricType {
+		return autoscaling.MetricValueStatus{AverageValue: quantity}
+	}
+	return autoscaling.MetricValueStatus{Value: quantity}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2021 The Custom Pod Autoscaler Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric"
+)
+
+type fakeMetricsClient struct {
+	getResourceMetricReactor func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error)
+	getExternalMetricReactor func(metricName string, namespace string, selector labels.Selector) ([]int64, time.Time, error)
+}
+
+func (f *fakeMetricsClient) GetResourceMetric(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return f.getResourceMetricReactor(resourceName, namespace, selector)
+}
+
+func (f *fakeMetricsClient) GetContainerResourceMetric(resourceName v1.ResourceName, namespace string, selector labels.Selector, container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return nil, time.Time{}, errors.New("not implemented")
+}
+
+func (f *fakeMetricsClient) GetRawMetric(metricName string, namespace string, selector labels.Selector, metricSelector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return nil, time.Time{}, errors.New("not implemented")
+}
+
+func (f *fakeMetricsClient) GetObjectMetric(metricName string, namespace string, objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) (int64, time.Time, error) {
+	return 0, time.Time{}, errors.New("not implemented")
+}
+
+func (f *fakeMetricsClient) GetExternalMetric(metricName string, namespace string, selector labels.Selector) ([]int64, time.Time, error) {
+	if f.getExternalMetricReactor == nil {
+		return nil, time.Time{}, errors.New("not implemented")
+	}
+	return f.getExternalMetricReactor(metricName, namespace, selector)
+}
+
+func statusPodLister(pods ...*v1.Pod) corelisters.PodLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range pods {
+		indexer.Add(pod)
+	}
+	return corelisters.NewPodLister(indexer)
+}
+
+func statusReadyPod(name string, cpuRequest string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceCPU: resource.MustParse(cpuRequest),
+						},
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase:     v1.PodRunning,
+			StartTime: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestGatherer_GetMetricsWithStatus(t *testing.T) {
+	t.Run("all metrics succeed", func(t *testing.T) {
+		gatherer := &metric.Gatherer{
+			MetricsClient: &fakeMetricsClient{
+				getResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+					return metricsclient.PodMetricsInfo{
+						"pod-1": metricsclient.PodMetric{Value: 50},
+					}, time.Unix(0, 0), nil
+				},
+			},
+			PodLister: statusPodLister(statusReadyPod("pod-1", "100m")),
+		}
+
+		targetUtilization := int32(80)
+		specs := []autoscaling.MetricSpec{
+			{
+				Type: autoscaling.ResourceMetricSourceType,
+				Resource: &autoscaling.ResourceMetricSource{
+					Name: v1.ResourceCPU,
+					Target: autoscaling.MetricTarget{
+						Type:               autoscaling.UtilizationMetricType,
+						AverageUtilization: &targetUtilization,
+					},
+				},
+			},
+		}
+
+		result, err := gatherer.GetMetricsWithStatus(
+			autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"},
+			&autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}},
+			specs,
+			"test-namespace",
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(result.Metrics) != 1 || result.Metrics[0] == nil {
+			t.Fatalf("expected a single gathered metric, got %+v", result.Metrics)
+		}
+		if len(result.PerMetricErrors) != 1 || result.PerMetricErrors[0] != nil {
+			t.Fatalf("expected no per-metric errors, got %+v", result.PerMetricErrors)
+		}
+
+		gotUtilization := result.Metrics[0].Status.Resource.Current.AverageUtilization
+		if gotUtilization == nil || *gotUtilization != 50 {
+			t.Errorf("expected an average utilization of 50, got %v", gotUtilization)
+		}
+
+		scalingActive := findCondition(t, result.Conditions, autoscaling.ScalingActive)
+		if scalingActive.Status != v1.ConditionTrue {
+			t.Errorf("expected ScalingActive to be true, got %q", scalingActive.Status)
+		}
+	})
+
+	t.Run("partial failure, some metrics succeed and some fail", func(t *testing.T) {
+		gatherer := &metric.Gatherer{
+			MetricsClient: &fakeMetricsClient{
+				getResourceMetricReactor: func(resourceName v1.ResourceName, namespace string, selector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+					return metricsclient.PodMetricsInfo{
+						"pod-1": metricsclient.PodMetric{Value: 50},
+					}, time.Unix(0, 0), nil
+				},
+			},
+			PodLister: statusPodLister(statusReadyPod("pod-1", "100m")),
+		}
+
+		targetUtilization := int32(80)
+		specs := []autoscaling.MetricSpec{
+			{Type: "invalid"},
+			{
+				Type: autoscaling.ResourceMetricSourceType,
+				Resource: &autoscaling.ResourceMetricSource{
+					Name: v1.ResourceCPU,
+					Target: autoscaling.MetricTarget{
+						Type:               autoscaling.UtilizationMetricType,
+						AverageUtilization: &targetUtilization,
+					},
+				},
+			},
+		}
+
+		result, err := gatherer.GetMetricsWithStatus(
+			autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"},
+			&autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}},
+			specs,
+			"test-namespace",
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(result.Metrics) != 2 {
+			t.Fatalf("expected 2 positionally aligned metric slots, got %d", len(result.Metrics))
+		}
+		if result.Metrics[0] != nil {
+			t.Errorf("expected the first metric slot to be nil (failed), got %+v", result.Metrics[0])
+		}
+		if result.Metrics[1] == nil {
+			t.Fatalf("expected the second metric slot to be populated (succeeded)")
+		}
+		if result.PerMetricErrors[0] == nil {
+			t.Errorf("expected the first metric slot to carry an error")
+		}
+		if result.PerMetricErrors[1] != nil {
+			t.Errorf("expected the second metric slot to have no error, got %v", result.PerMetricErrors[1])
+		}
+
+		scalingActive := findCondition(t, result.Conditions, autoscaling.ScalingActive)
+		if scalingActive.Status != v1.ConditionTrue {
+			t.Errorf("expected ScalingActive to be true since one metric succeeded, got %q", scalingActive.Status)
+		}
+	})
+
+	t.Run("every metric fails", func(t *testing.T) {
+		gatherer := &metric.Gatherer{
+			MetricsClient: &fakeMetricsClient{},
+			PodLister:     statusPodLister(),
+		}
+
+		specs := []autoscaling.MetricSpec{
+			{Type: "invalid"},
+		}
+
+		result, err := gatherer.GetMetricsWithStatus(
+			autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "test"},
+			&autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}},
+			specs,
+			"test-namespace",
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Metrics[0] != nil {
+			t.Errorf("expected the only metric slot to be nil, got %+v", result.Metrics[0])
+		}
+
+		scalingActive := findCondition(t, result.Conditions, autoscaling.ScalingActive)
+		if scalingActive.Status != v1.ConditionFalse {
+			t.Errorf("expected ScalingActive to be false, got %q", scalingActive.Status)
+		}
+	})
+}
+
+func findCondition(t *testing.T, conditions []autoscaling.HorizontalPodAutoscalerCondition, conditionType autoscaling.HorizontalPodAutoscalerConditionType) autoscaling.HorizontalPodAutoscalerCondition {
+	t.Helper()
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition
+		}
+	}
+	t.Fatalf("expected a %q condition, got %+v", conditionType, conditions)
+	return autoscaling.HorizontalPodAutoscalerCondition{}
+}
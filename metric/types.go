@@ -19,21 +19,45 @@ package metric
 import (
 	"time"
 
-	autoscaling "k8s.io/api/autoscaling/v2beta2"
+	autoscaling "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/util/sets"
 	metricsclient "k8s.io/kubernetes/pkg/controller/podautoscaler/metrics"
+
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/node"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prediction"
+	"github.com/jthomperoo/horizontal-pod-autoscaler/metric/prometheus"
 )
 
 // CombinedMetric represents a metric that has been gathered using a MetricSpec, it can be any of the types of
 // metrics within the CombinedMetric as each is optional. The CombinedMetric also provides the Spec used to
 // gather the metric, alongside the CurrentReplicas at time of gathering.
 type CombinedMetric struct {
-	CurrentReplicas int32                  `json:"current_replicas"`
-	Spec            autoscaling.MetricSpec `json:"spec"`
-	Resource        *ResourceMetric        `json:"resource,omitempty"`
-	Pods            *PodsMetric            `json:"pods,omitempty"`
-	Object          *ObjectMetric          `json:"object,omitempty"`
-	External        *ExternalMetric        `json:"external,omitempty"`
+	CurrentReplicas   int32                  `json:"current_replicas"`
+	Spec              autoscaling.MetricSpec `json:"spec"`
+	Resource          *ResourceMetric        `json:"resource,omitempty"`
+	ContainerResource *ResourceMetric        `json:"container_resource,omitempty"`
+	Pods              *PodsMetric            `json:"pods,omitempty"`
+	Object            *ObjectMetric          `json:"object,omitempty"`
+	External          *ExternalMetric        `json:"external,omitempty"`
+	Prometheus        *prometheus.Metric     `json:"prometheus,omitempty"`
+	Prediction        *prediction.Metric     `json:"prediction,omitempty"`
+	Node              *node.Metric           `json:"node,omitempty"`
+	// Status is the Kubernetes-style MetricStatus equivalent of the gathered metric, in the same shape the
+	// HorizontalPodAutoscaler's Status.CurrentMetrics would hold, so that callers writing that status back don't
+	// need to re-derive it from the gatherer-specific fields above.
+	Status autoscaling.MetricStatus `json:"status"`
+}
+
+// GatherResult is the outcome of gathering every MetricSpec passed to GetMetricsWithStatus. Metrics and
+// PerMetricErrors are aligned positionally with the MetricSpec slice passed in: for index i, either Metrics[i] is
+// populated and PerMetricErrors[i] is nil, or Metrics[i] is nil and PerMetricErrors[i] holds the reason that
+// metric couldn't be gathered. Conditions summarises the overall gathering attempt, mirroring the AbleToScale,
+// ScalingActive and ScalingLimited conditions kube-controller-manager's horizontal.go sets on a
+// HorizontalPodAutoscaler's Status.Conditions.
+type GatherResult struct {
+	Metrics         []*CombinedMetric                              `json:"metrics"`
+	PerMetricErrors []error                                        `json:"per_metric_errors"`
+	Conditions      []autoscaling.HorizontalPodAutoscalerCondition `json:"conditions"`
 }
 
 // ResourceMetric is a resource metric known to Kubernetes, as